@@ -0,0 +1,215 @@
+package aml
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/literal"
+	"github.com/acorn-io/aml/pkg/amlparser"
+	"github.com/acorn-io/aml/pkg/cue"
+	"github.com/acorn-io/aml/pkg/definition"
+	"sigs.k8s.io/yaml"
+)
+
+// ParseArgs converts args given as strings, for example as command line
+// flags, to the types of the params in spec so they can be passed in
+// Options.Args.
+//
+//   - int and float args accept the AML number syntax, such as 1_000 or 2Ki
+//   - bool args accept the values understood by strconv.ParseBool
+//   - enum args must be one of the options of the param
+//   - array args are a comma separated list of strings or a JSON or AML list
+//   - object args are a JSON or AML struct
+//   - args that may be of more than one type are a JSON or AML value, or
+//     else a string
+//
+// A value of the form @file is replaced by the contents of the file, without
+// its trailing newline. Files ending in .yaml or .yml are read as YAML for
+// array and object args. A value starting with @@ is not read from a file,
+// the first @ is dropped instead, so @@latest becomes @latest.
+//
+// Args not in spec are returned unchanged. If any arg can not be converted
+// an *Error is returned describing each of them.
+func ParseArgs(spec *definition.ParamSpec, args map[string]string) (map[string]any, error) {
+	params := map[string]definition.Param{}
+	if spec != nil {
+		for _, param := range spec.Params {
+			params[param.Name] = param
+		}
+	}
+
+	var names []string
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		result      = map[string]any{}
+		diagnostics []Diagnostic
+	)
+	for _, name := range names {
+		param, ok := params[name]
+		if !ok {
+			result[name] = args[name]
+			continue
+		}
+		value, err := parseArg(param, args[name])
+		if err != nil {
+			diagnostics = append(diagnostics, argDiagnostic(name, err))
+			continue
+		}
+		result[name] = value
+	}
+
+	if len(diagnostics) > 0 {
+		return nil, argsError(diagnostics)
+	}
+	return result, nil
+}
+
+func argDiagnostic(name string, err error) Diagnostic {
+	return Diagnostic{
+		Path:     "args." + name,
+		Severity: SeverityError,
+		Message:  err.Error(),
+	}
+}
+
+func argsError(diagnostics []Diagnostic) *Error {
+	return &Error{
+		Diagnostics: diagnostics,
+		err:         fmt.Errorf("invalid args"),
+	}
+}
+
+func parseArg(param definition.Param, s string) (any, error) {
+	var filename string
+	if strings.HasPrefix(s, "@@") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "@") {
+		filename = s[1:]
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		s = string(data)
+		if param.Type == "string" {
+			s = strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r")
+		} else {
+			s = strings.TrimSpace(s)
+		}
+	}
+
+	switch param.Type {
+	case "string":
+		return s, nil
+	case "enum":
+		for _, option := range param.Options {
+			if s == option {
+				return s, nil
+			}
+		}
+		var quoted []string
+		for _, option := range param.Options {
+			quoted = append(quoted, strconv.Quote(option))
+		}
+		return nil, fmt.Errorf("invalid value %q, expected one of %s", s, strings.Join(quoted, ", "))
+	case "int":
+		if i, err := ParseInt(s); err == nil {
+			return i, nil
+		}
+		return nil, fmt.Errorf("invalid value %q, expected int", s)
+	case "float":
+		info := literal.NumInfo{}
+		if err := literal.ParseNum(s, &info); err == nil {
+			if f, err := strconv.ParseFloat(info.String(), 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid value %q, expected float", s)
+	case "bool":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+		return nil, fmt.Errorf("invalid value %q, expected bool", s)
+	case "array":
+		if filename == "" && !strings.HasPrefix(s, "[") {
+			result := []any{}
+			if s == "" {
+				return result, nil
+			}
+			for _, item := range strings.Split(s, ",") {
+				result = append(result, strings.TrimSpace(item))
+			}
+			return result, nil
+		}
+		value, err := parseLiteral(filename, s)
+		if _, ok := value.([]any); err == nil && !ok {
+			err = fmt.Errorf("got %s", kindOf(value))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q, expected array: %w", s, err)
+		}
+		return value, nil
+	case "":
+		// A value of more than one type is kept as a string unless it is
+		// a literal
+		if value, err := parseLiteral(filename, s); err == nil {
+			return value, nil
+		}
+		return s, nil
+	}
+
+	value, err := parseLiteral(filename, s)
+	if _, ok := value.(map[string]any); err == nil && !ok {
+		err = fmt.Errorf("got %s", kindOf(value))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q, expected object: %w", s, err)
+	}
+	return value, nil
+}
+
+// kindOf returns the AML kind of a value decoded by parseLiteral.
+func kindOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "struct"
+	}
+	return "number"
+}
+
+// parseLiteral decodes a JSON or AML value, or YAML if read from a file
+// ending in .yaml or .yml.
+func parseLiteral(filename, s string) (any, error) {
+	var result any
+	switch filepath.Ext(filename) {
+	case ".yaml", ".yml":
+		return result, yaml.Unmarshal([]byte(s), &result)
+	}
+
+	ctx := cue.NewContext().WithFiles(cue.File{
+		Filename:    "arg.cue",
+		DisplayName: "arg",
+		Data:        []byte(s),
+		Parser:      amlparser.ParseFile,
+	})
+	v, err := ctx.ValueNoSchema()
+	if err != nil {
+		return nil, err
+	}
+	return result, ctx.Decode(v, &result)
+}
@@ -0,0 +1,116 @@
+package aml
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testArgsAcornfile = `
+args: {
+	name: "web"
+	replicas: 1
+	ratio: 0.5
+	debug: false
+	mode: "dev" | "prod" | "test"
+	tags: ["a"]
+	env: {}
+	port: int | string
+}
+
+containers: web: image: "nginx"
+`
+
+func TestParseArgs(t *testing.T) {
+	spec, err := NewDecoder(strings.NewReader(testArgsAcornfile)).Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "env.yaml"), []byte("FOO: bar\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "name.txt"), []byte("api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := ParseArgs(spec, map[string]string{
+		"name":     "@" + filepath.Join(dir, "name.txt"),
+		"replicas": "1_000",
+		"ratio":    "1.5",
+		"debug":    "true",
+		"mode":     "prod",
+		"tags":     "x, y",
+		"env":      "@" + filepath.Join(dir, "env.yaml"),
+		"other":    "value",
+		"port":     "8080",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]any{
+		"name":     "api",
+		"replicas": int64(1000),
+		"ratio":    1.5,
+		"debug":    true,
+		"mode":     "prod",
+		"tags":     []any{"x", "y"},
+		"env":      map[string]any{"FOO": "bar"},
+		"other":    "value",
+		"port":     float64(8080),
+	}, args)
+
+	args, err = ParseArgs(spec, map[string]string{
+		"tags": `["x", 1]`,
+		"env":  `{FOO: "bar", n: 1 + 1}`,
+		"port": "http",
+		"name": "@@latest",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []any{"x", float64(1)}, args["tags"])
+	assert.Equal(t, map[string]any{"FOO": "bar", "n": float64(2)}, args["env"])
+	assert.Equal(t, "http", args["port"])
+	assert.Equal(t, "@latest", args["name"])
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	spec, err := NewDecoder(strings.NewReader(testArgsAcornfile)).Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tags.yaml"), []byte("a: b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseArgs(spec, map[string]string{
+		"replicas": "many",
+		"debug":    "maybe",
+		"mode":     "staging",
+		"env":      "[1]",
+		"tags":     "@" + filepath.Join(dir, "tags.yaml"),
+	})
+	var amlErr *Error
+	if !errors.As(err, &amlErr) {
+		t.Fatalf("expected *Error, got %T: %v", err, err)
+	}
+	assert.Len(t, amlErr.Diagnostics, 5)
+	assert.Equal(t, "args.debug", amlErr.Diagnostics[0].Path)
+	assert.Equal(t, `invalid value "maybe", expected bool`, amlErr.Diagnostics[0].Message)
+	assert.Equal(t, "args.env", amlErr.Diagnostics[1].Path)
+	assert.Equal(t, `invalid value "[1]", expected object: got list`, amlErr.Diagnostics[1].Message)
+	assert.Equal(t, "args.mode", amlErr.Diagnostics[2].Path)
+	assert.Equal(t, `invalid value "staging", expected one of "dev", "prod", "test"`, amlErr.Diagnostics[2].Message)
+	assert.Equal(t, "args.replicas", amlErr.Diagnostics[3].Path)
+	assert.Equal(t, `invalid value "many", expected int`, amlErr.Diagnostics[3].Message)
+	assert.Equal(t, "args.tags", amlErr.Diagnostics[4].Path)
+	assert.Equal(t, `invalid value "a: b", expected array: got struct`, amlErr.Diagnostics[4].Message)
+}
@@ -1,15 +1,22 @@
 package aml
 
 import (
+	"fmt"
 	"io"
+	"io/fs"
 
+	"cuelang.org/go/cue/ast"
+	"github.com/acorn-io/aml/pkg/amlparser"
+	"github.com/acorn-io/aml/pkg/cue"
 	"github.com/acorn-io/aml/pkg/definition"
 	"github.com/acorn-io/aml/pkg/loader"
+	"github.com/acorn-io/aml/pkg/parser"
 )
 
 type Options struct {
 	Args     map[string]any
 	Profiles []string
+	Schema   *Schema
 }
 
 func (d Options) ApplyTo(opts *Options) {
@@ -23,15 +30,64 @@ func (d Options) ApplyTo(opts *Options) {
 	}
 
 	opts.Profiles = append(opts.Profiles, d.Profiles...)
+
+	if d.Schema != nil {
+		opts.Schema = d.Schema
+	}
 }
 
 type Option interface {
 	ApplyTo(d *Options)
 }
 
+// Schema replaces the Acornfile schema the input is validated against. When
+// set, Decode returns the whole evaluated document, excluding the args,
+// profiles and std fields, rather than only the Acornfile sections. The schema
+// is read from Source, parsed as AML, or from all .aml and .cue files in FS.
+// A Schema can only be passed to NewDecoder.
+type Schema struct {
+	// TypeName is the definition the document must satisfy, for example "#Config"
+	TypeName string
+	Source   []byte
+	FS       fs.FS
+}
+
+func (s Schema) ApplyTo(opts *Options) {
+	opts.Schema = &s
+}
+
+func (s *Schema) toDefinition() (*definition.CustomSchema, error) {
+	result := &definition.CustomSchema{
+		TypeName: s.TypeName,
+	}
+	if len(s.Source) > 0 {
+		result.Files = append(result.Files, cue.File{
+			Filename:    "schema.cue",
+			DisplayName: "schema",
+			Data:        s.Source,
+			Parser:      amlparser.ParseFile,
+		})
+	}
+	if s.FS != nil {
+		files, err := loader.FSToFiles(s.FS)
+		if err != nil {
+			return nil, err
+		}
+		result.Files = append(result.Files, files...)
+	}
+	return result, nil
+}
+
+// Decoder reads and compiles its input once, on first use. All further calls
+// are served from the same compiled definition, so Args, ComputedArgs and
+// Decode may be called any number of times.
 type Decoder struct {
 	opts  *Options
 	input io.Reader
+	files []cue.File
+	src   *source
+	def   *definition.Definition
+	err   error
 }
 
 func NewDecoder(input io.Reader, options ...Option) *Decoder {
@@ -45,46 +101,131 @@ func NewDecoder(input io.Reader, options ...Option) *Decoder {
 	}
 }
 
-func (d *Decoder) Args() (*definition.ParamSpec, error) {
+func (d *Decoder) definition() (*definition.Definition, error) {
+	if d.def != nil || d.err != nil {
+		return d.def, d.err
+	}
+
+	d.def, d.err = d.newDefinition()
+	return d.def, d.err
+}
+
+func (d *Decoder) newDefinition() (*definition.Definition, error) {
 	files, err := loader.ToFiles(d.input)
 	if err != nil {
 		return nil, err
 	}
-	def, err := definition.NewDefinition(files)
+	d.files = files
+
+	if d.opts.Schema == nil {
+		return definition.NewDefinition(files)
+	}
+
+	schema, err := d.opts.Schema.toDefinition()
 	if err != nil {
 		return nil, err
 	}
-	return def.Args()
+	return definition.NewDefinitionWithSchema(files, schema)
 }
 
-func (d *Decoder) ComputedArgs() (map[string]any, error) {
-	files, err := loader.ToFiles(d.input)
-	if err != nil {
-		return nil, err
+// newError converts err to an *Error, translating errors in desugared
+// function calls back to the calls found in the input.
+func (d *Decoder) newError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if d.src == nil {
+		var files []*ast.File
+		for _, f := range d.files {
+			if f.Parser == nil {
+				continue
+			}
+			name := f.DisplayName
+			if name == "" {
+				name = f.Filename
+			}
+			file, _ := parser.ParseFile(name, f.Data, parser.ParseComments)
+			if file != nil {
+				files = append(files, file)
+			}
+		}
+		d.src = newSource(files...)
+	}
+	return newError(err, d.src)
+}
+
+// options returns the options given to NewDecoder with the supplied options
+// applied on top. A Schema can not be changed once the input is compiled, so
+// it is rejected in the supplied options.
+func (d *Decoder) options(options []Option) (*Options, error) {
+	opts := &Options{}
+	for _, opt := range options {
+		opt.ApplyTo(opts)
+	}
+	if opts.Schema != nil {
+		return nil, fmt.Errorf("a Schema can only be passed to NewDecoder")
 	}
-	def, err := definition.NewDefinition(files)
+
+	result := &Options{}
+	d.opts.ApplyTo(result)
+	opts.ApplyTo(result)
+	return result, nil
+}
+
+// Args returns the args and profiles declared in the input. All errors
+// returned by the Decoder are of type *Error.
+func (d *Decoder) Args() (*definition.ParamSpec, error) {
+	def, err := d.definition()
 	if err != nil {
-		return nil, err
+		return nil, d.newError(err)
 	}
+	spec, err := def.Args()
+	return spec, d.newError(err)
+}
 
-	_, computed, err := def.WithArgs(d.opts.Args, d.opts.Profiles)
-	return computed, err
+// ArgsJSONSchema returns a JSON Schema describing the args declared in the
+// input, see definition.Definition.ArgsJSONSchema.
+func (d *Decoder) ArgsJSONSchema() ([]byte, error) {
+	def, err := d.definition()
+	if err != nil {
+		return nil, d.newError(err)
+	}
+	schema, err := def.ArgsJSONSchema()
+	return schema, d.newError(err)
 }
 
-func (d *Decoder) Decode(v any) error {
-	files, err := loader.ToFiles(d.input)
+// ComputedArgs returns the args after the requested profiles are applied.
+// The options are applied on top of the options given to NewDecoder.
+func (d *Decoder) ComputedArgs(options ...Option) (map[string]any, error) {
+	opts, err := d.options(options)
+	if err != nil {
+		return nil, d.newError(err)
+	}
+
+	def, err := d.definition()
 	if err != nil {
-		return err
+		return nil, d.newError(err)
 	}
-	def, err := definition.NewDefinition(files)
+	_, computed, err := def.WithArgs(opts.Args, opts.Profiles)
+	return computed, d.newError(err)
+}
+
+// Decode evaluates the input and decodes the result into v. The options are
+// applied on top of the options given to NewDecoder.
+func (d *Decoder) Decode(v any, options ...Option) error {
+	opts, err := d.options(options)
 	if err != nil {
-		return err
+		return d.newError(err)
 	}
 
-	def, _, err = def.WithArgs(d.opts.Args, d.opts.Profiles)
+	def, err := d.definition()
+	if err != nil {
+		return d.newError(err)
+	}
+	def, _, err = def.WithArgs(opts.Args, opts.Profiles)
 	if err != nil {
-		return err
+		return d.newError(err)
 	}
 
-	return def.Decode(v)
+	return d.newError(def.Decode(v))
 }
@@ -0,0 +1,251 @@
+package aml
+
+import (
+	"archive/tar"
+	"bytes"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAcornfile = `
+args: {
+	// Number of replicas
+	replicas: 1
+}
+
+profiles: prod: replicas: 3
+
+containers: web: {
+	image: "nginx"
+	scale: args.replicas
+}
+`
+
+func TestDecoderReuse(t *testing.T) {
+	d := NewDecoder(strings.NewReader(testAcornfile))
+
+	spec, err := d.Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "replicas", spec.Params[0].Name)
+	assert.Equal(t, "prod", spec.Profiles[0].Name)
+
+	computed, err := d.ComputedArgs(Options{Profiles: []string{"prod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, computed["replicas"])
+
+	for _, test := range []struct {
+		opts  Options
+		scale float64
+	}{
+		{scale: 1},
+		{opts: Options{Profiles: []string{"prod"}}, scale: 3},
+		{opts: Options{Args: map[string]any{"replicas": 2}}, scale: 2},
+		{scale: 1},
+	} {
+		result := map[string]any{}
+		if err := d.Decode(&result, test.opts); err != nil {
+			t.Fatal(err)
+		}
+		web := result["containers"].(map[string]any)["web"].(map[string]any)
+		assert.Equal(t, test.scale, web["scale"])
+	}
+}
+
+func TestDecoderCachesError(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`containers: web: image: 1`))
+
+	err := d.Decode(&map[string]any{})
+	assert.Error(t, err)
+	assert.Equal(t, err, d.Decode(&map[string]any{}))
+
+	_, err = d.Args()
+	assert.Error(t, err)
+}
+
+const testSchema = `
+#Config: {
+	name:     string
+	replicas: int & >0
+	tags: [...string]
+}
+`
+
+const testConfig = `
+args: {
+	replicas: 1
+}
+
+profiles: prod: replicas: 3
+
+name:     std.toUpper("app")
+replicas: args.replicas
+tags: ["a", "b"]
+`
+
+func TestDecoderStdCallInArgs(t *testing.T) {
+	result := map[string]any{}
+	err := NewDecoder(strings.NewReader(`
+args: name: std.toUpper("web")
+containers: web: image: args.name
+`)).Decode(&result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "WEB", result["containers"].(map[string]any)["web"].(map[string]any)["image"])
+}
+
+func TestDecoderSchema(t *testing.T) {
+	d := NewDecoder(strings.NewReader(testConfig), Schema{
+		TypeName: "#Config",
+		Source:   []byte(testSchema),
+	})
+
+	result := map[string]any{}
+	if err := d.Decode(&result, Options{Profiles: []string{"prod"}}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]any{
+		"name":     "APP",
+		"replicas": float64(3),
+		"tags":     []any{"a", "b"},
+	}, result)
+}
+
+func TestDecoderSchemaFS(t *testing.T) {
+	schemaFS := fstest.MapFS{
+		"config/schema.cue": &fstest.MapFile{
+			Data: []byte("package config\n" + testSchema),
+		},
+	}
+
+	d := NewDecoder(strings.NewReader(testConfig), Schema{
+		TypeName: "#Config",
+		FS:       schemaFS,
+	})
+	_, err := d.ComputedArgs()
+	assert.NoError(t, err)
+
+	d = NewDecoder(strings.NewReader(testConfig+"\nother: 1\n"), Schema{
+		TypeName: "#Config",
+		FS:       schemaFS,
+	})
+	err = d.Decode(&map[string]any{})
+	assert.ErrorContains(t, err, "other")
+}
+
+func TestDecoderSchemaFSNames(t *testing.T) {
+	schemaFS := fstest.MapFS{
+		"a/b.cue":  &fstest.MapFile{Data: []byte("#Config: name: string\n")},
+		"a_b.cue":  &fstest.MapFile{Data: []byte("#Config: replicas: int\n")},
+		"args.cue": &fstest.MapFile{Data: []byte("#Config: tags: [...string]\n")},
+	}
+
+	result := map[string]any{}
+	err := NewDecoder(strings.NewReader(testConfig), Schema{
+		TypeName: "#Config",
+		FS:       schemaFS,
+	}).Decode(&result, Options{Args: map[string]any{"replicas": 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]any{
+		"name":     "APP",
+		"replicas": float64(2),
+		"tags":     []any{"a", "b"},
+	}, result)
+}
+
+func TestDecoderSchemaTypeName(t *testing.T) {
+	for _, name := range []string{"Config", "#Config: _\n#Other", "#Config-1"} {
+		err := NewDecoder(strings.NewReader(testConfig), Schema{
+			TypeName: name,
+			Source:   []byte(testSchema),
+		}).Decode(&map[string]any{})
+		assert.ErrorContains(t, err, "is not a definition such as #Config")
+	}
+}
+
+func TestDecoderSchemaPerCall(t *testing.T) {
+	d := NewDecoder(strings.NewReader(testConfig))
+	err := d.Decode(&map[string]any{}, Schema{
+		TypeName: "#Config",
+		Source:   []byte(testSchema),
+	})
+	assert.ErrorContains(t, err, "a Schema can only be passed to NewDecoder")
+
+	_, err = d.ComputedArgs(Options{Schema: &Schema{TypeName: "#Config"}})
+	assert.ErrorContains(t, err, "a Schema can only be passed to NewDecoder")
+}
+
+func TestDecoderSchemaQuotedKey(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`"app-name": "web"`), Schema{
+		TypeName: "#Config",
+		Source:   []byte(`#Config: "app-name": string`),
+	})
+
+	result := map[string]any{}
+	if err := d.Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]any{
+		"app-name": "web",
+	}, result)
+}
+
+func TestDecoderBundledFiles(t *testing.T) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for name, content := range map[string]string{
+		"Acornfile.aml": `
+args: port: 8080
+containers: web: {
+	image: "nginx"
+	env: {
+		NAME: std.readYAML("config/app.yaml").name
+		REPLICAS: "\(std.readJSON("config/scale.json").replicas)"
+		CONFIGS: std.join(std.glob("config/*"), ",")
+		ALL: std.join(std.glob("**.json"), ",")
+		RAW: std.files["config/scale.json"]
+	}
+	files: "/etc/nginx/nginx.conf": std.renderFile("nginx.conf.tmpl", {port: args.port})
+}
+`,
+		"config/app.yaml":   "name: demo\n",
+		"config/scale.json": `{"replicas": 3}`,
+		"nginx.conf.tmpl":   "listen {{.port}};\n",
+	} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+
+	result := map[string]any{}
+	if err := NewDecoder(buf).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	web := result["containers"].(map[string]any)["web"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"NAME":     "demo",
+		"REPLICAS": "3",
+		"CONFIGS":  "config/app.yaml,config/scale.json",
+		"ALL":      "config/scale.json",
+		"RAW":      `{"replicas": 3}`,
+	}, web["env"])
+	assert.Equal(t, map[string]any{
+		"/etc/nginx/nginx.conf": "listen 8080;\n",
+	}, web["files"])
+}
@@ -0,0 +1,351 @@
+package aml
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	numberType        = reflect.TypeOf(json.Number(""))
+)
+
+// Encoder writes Go values as formatted AML source. Values are converted
+// following the same rules as encoding/json, including the "json" struct tag.
+// The "doc" struct tag is written as the doc comment of the field.
+type Encoder struct {
+	w io.Writer
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: w,
+	}
+}
+
+// Encode writes the AML encoding of v. The value must encode to a struct, that
+// is v must be a struct, a map or a pointer to either.
+func (e *Encoder) Encode(v any) error {
+	expr, err := encode(reflect.ValueOf(v), 0)
+	if err != nil {
+		return err
+	}
+
+	s, ok := expr.(*ast.StructLit)
+	if !ok {
+		return fmt.Errorf("aml: can not encode %T, must be a struct or map", v)
+	}
+
+	data, err := format.Node(&ast.File{Decls: s.Elts})
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+func encode(v reflect.Value, depth int) (ast.Expr, error) {
+	if !v.IsValid() {
+		return ast.NewNull(), nil
+	}
+
+	if v.Kind() != reflect.Pointer || !v.IsNil() {
+		if v.Type().Implements(jsonMarshalerType) {
+			return encodeJSONMarshaler(v.Interface().(json.Marshaler), depth)
+		}
+		if v.Type().Implements(textMarshalerType) {
+			text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			return encodeString(string(text), depth), nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return ast.NewNull(), nil
+		}
+		return encode(v.Elem(), depth)
+	case reflect.Bool:
+		return ast.NewBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ast.NewLit(token.INT, strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return ast.NewLit(token.INT, strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		return encodeFloat(v.Float(), v.Type().Bits())
+	case reflect.String:
+		if v.Type() == numberType {
+			return encodeNumber(v.String())
+		}
+		return encodeString(v.String(), depth), nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return ast.NewNull(), nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return &ast.BasicLit{
+				Kind:  token.STRING,
+				Value: literal.Bytes.Quote(string(v.Bytes())),
+			}, nil
+		}
+		fallthrough
+	case reflect.Array:
+		list := &ast.ListLit{}
+		for i := 0; i < v.Len(); i++ {
+			elem, err := encode(v.Index(i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list.Elts = append(list.Elts, elem)
+		}
+		return list, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return ast.NewNull(), nil
+		}
+		return encodeMap(v, depth)
+	case reflect.Struct:
+		s := &ast.StructLit{}
+		return s, encodeStruct(s, v, depth, map[string]bool{})
+	}
+
+	return nil, fmt.Errorf("aml: unsupported type %s", v.Type())
+}
+
+func encodeJSONMarshaler(m json.Marshaler, depth int) (ast.Expr, error) {
+	data, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var obj any
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+
+	return encode(reflect.ValueOf(obj), depth)
+}
+
+func encodeFloat(f float64, bits int) (ast.Expr, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("aml: unsupported float value %v", f)
+	}
+	s := strconv.FormatFloat(f, 'g', -1, bits)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return ast.NewLit(token.FLOAT, s), nil
+}
+
+func encodeNumber(s string) (ast.Expr, error) {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ast.NewLit(token.INT, s), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	return encodeFloat(f, 64)
+}
+
+func encodeString(s string, depth int) ast.Expr {
+	form := literal.String
+	if strings.Contains(s, "\n") {
+		form = form.WithTabIndent(depth + 1)
+	}
+	return &ast.BasicLit{
+		Kind:  token.STRING,
+		Value: form.Quote(s),
+	}
+}
+
+func encodeMap(v reflect.Value, depth int) (ast.Expr, error) {
+	var (
+		keys  []string
+		index = map[string]reflect.Value{}
+	)
+
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		index[key] = iter.Value()
+	}
+	sort.Strings(keys)
+
+	s := &ast.StructLit{}
+	for _, key := range keys {
+		value, err := encode(index[key], depth+1)
+		if err != nil {
+			return nil, err
+		}
+		s.Elts = append(s.Elts, &ast.Field{
+			Label: label(key),
+			Value: value,
+		})
+	}
+	return s, nil
+}
+
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("aml: unsupported map key type %s", k.Type())
+}
+
+func encodeStruct(s *ast.StructLit, v reflect.Value, depth int, seen map[string]bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := encodeStruct(s, fv, depth, seen); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if hasOption(opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+
+		value, err := encode(fv, depth+1)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if hasOption(opts, "string") {
+			if lit, ok := value.(*ast.BasicLit); ok && lit.Kind != token.STRING && lit.Kind != token.NULL {
+				value = ast.NewString(lit.Value)
+			}
+		}
+
+		field := &ast.Field{
+			Label: label(name),
+			Value: value,
+		}
+		if doc := sf.Tag.Get("doc"); doc != "" {
+			ast.AddComment(field, docComment(doc))
+		}
+		s.Elts = append(s.Elts, field)
+	}
+
+	return nil
+}
+
+func docComment(doc string) *ast.CommentGroup {
+	cg := &ast.CommentGroup{
+		Doc: true,
+	}
+	for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		cg.List = append(cg.List, &ast.Comment{
+			Text: strings.TrimSpace("// " + line),
+		})
+	}
+	return cg
+}
+
+// label returns an unquoted identifier for name if it is a valid regular
+// identifier, otherwise a quoted string.
+func label(name string) ast.Label {
+	if ast.IsValidIdent(name) && !strings.HasPrefix(name, "_") && !strings.HasPrefix(name, "#") && !reservedLabels[name] {
+		return ast.NewIdent(name)
+	}
+	return ast.NewString(name)
+}
+
+var reservedLabels = map[string]bool{
+	"true":    true,
+	"false":   true,
+	"null":    true,
+	"import":  true,
+	"package": true,
+}
+
+func hasOption(opts, option string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
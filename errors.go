@@ -0,0 +1,529 @@
+package aml
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+	"github.com/acorn-io/aml/pkg/amlparser"
+	"github.com/acorn-io/aml/pkg/cue"
+	"github.com/acorn-io/aml/pkg/definition"
+	"github.com/acorn-io/aml/pkg/std"
+	"github.com/acorn-io/baaah/pkg/merr"
+)
+
+type Severity string
+
+const (
+	SeverityError Severity = "error"
+)
+
+// Error is returned by the Decoder and Unmarshal for all failures. It
+// describes each problem found as a Diagnostic.
+type Error struct {
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+
+	err error
+}
+
+// Diagnostic is a single problem found in the input. Positions are 1 based and
+// are left empty if they are not known. The end position is exclusive. It is
+// the end of the expression or field the problem is found at, or empty if the
+// problem is not found in the input.
+type Diagnostic struct {
+	Filename  string   `json:"filename,omitempty"`
+	Line      int      `json:"line,omitempty"`
+	Column    int      `json:"column,omitempty"`
+	EndLine   int      `json:"endLine,omitempty"`
+	EndColumn int      `json:"endColumn,omitempty"`
+	Path      string   `json:"path,omitempty"`
+	Severity  Severity `json:"severity,omitempty"`
+	Message   string   `json:"message,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+	buf := &strings.Builder{}
+	if d.Path != "" {
+		buf.WriteString(d.Path)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(d.Message)
+	if d.Filename != "" {
+		fmt.Fprintf(buf, ":\n    %s:%d:%d", d.Filename, d.Line, d.Column)
+	}
+	return buf.String()
+}
+
+func (e *Error) Error() string {
+	if len(e.Diagnostics) == 0 {
+		return e.err.Error()
+	}
+	buf := &strings.Builder{}
+	for _, d := range e.Diagnostics {
+		buf.WriteString(d.String())
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// source is what is known about the parsed input to report errors in it.
+type source struct {
+	// calls are the desugared function calls found in the input
+	calls []*amlparser.Call
+	// ends maps the start of each node of the input to the end of the
+	// outermost node starting there
+	ends map[sourcePos]token.Pos
+}
+
+type sourcePos struct {
+	filename string
+	offset   int
+}
+
+func newSource(files ...*ast.File) *source {
+	result := &source{
+		ends: map[sourcePos]token.Pos{},
+	}
+	for _, file := range files {
+		result.calls = append(result.calls, amlparser.Calls(file)...)
+		ast.Walk(file, func(n ast.Node) bool {
+			if start := n.Pos(); start.IsValid() && n.End().IsValid() {
+				key := sourcePos{start.Filename(), start.Offset()}
+				if _, ok := result.ends[key]; !ok {
+					result.ends[key] = n.End()
+				}
+			}
+			return true
+		}, nil)
+	}
+	return result
+}
+
+// end returns the end of the outermost node of the input starting at pos.
+func (s *source) end(pos token.Pos) (token.Pos, bool) {
+	if !pos.IsValid() {
+		return token.NoPos, false
+	}
+	end, ok := s.ends[sourcePos{pos.Filename(), pos.Offset()}]
+	return end, ok
+}
+
+// newError converts err into an *Error, returning nil if err is nil. Errors
+// caused by the desugared form of the calls in src are reported against the
+// call as written.
+func newError(err error, src *source) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	return &Error{
+		Diagnostics: diagnostics(err, src),
+		err:         err,
+	}
+}
+
+func diagnostics(err error, src *source) (result []Diagnostic) {
+	if errs, ok := err.(merr.Errors); ok {
+		for _, err := range errs {
+			result = append(result, diagnostics(err, src)...)
+		}
+		return result
+	}
+
+	if argsErr, ok := err.(*definition.ArgsError); ok {
+		for _, argErr := range argsErr.Errors {
+			result = append(result, argDiagnostic(argErr.Name, argErr))
+		}
+		return result
+	}
+
+	if cueErr, ok := err.(cueerrors.Error); ok {
+		var (
+			argErrors   = map[*amlparser.Call]bool{}
+			arityErrors = map[*amlparser.Call]int{}
+			attributed  = map[string]bool{}
+			// errors inside the std function of that name that could not
+			// be tied to a call
+			internal = map[int]string{}
+			seen     = map[Diagnostic]bool{}
+		)
+		for _, err := range cueerrors.Errors(cueerrors.Sanitize(cueErr)) {
+			d, call, kind := callDiagnostic(err, src)
+			if seen[d] {
+				continue
+			}
+			seen[d] = true
+			switch kind {
+			case argumentError:
+				argErrors[call] = true
+			case signatureError:
+				if _, seen := arityErrors[call]; seen {
+					continue
+				}
+				arityErrors[call] = len(result)
+			case notCallError:
+				if name, ok := stdInternalError(err); ok {
+					internal[len(result)] = "std." + name
+				}
+			}
+			if call != nil {
+				attributed[call.Name] = true
+			}
+			result = append(result, d)
+		}
+		return dropSignatureErrors(result, argErrors, arityErrors, attributed, internal)
+	}
+
+	if u := errors.Unwrap(err); u != nil {
+		return diagnostics(u, src)
+	}
+
+	return []Diagnostic{{
+		Severity: SeverityError,
+		Message:  err.Error(),
+	}}
+}
+
+// dropSignatureErrors removes the generic signature errors of calls for which
+// a more specific argument error is reported. Errors inside a std function
+// that could not be tied to a call are removed if an error is reported for a
+// call to the same function.
+func dropSignatureErrors(diags []Diagnostic, argErrors map[*amlparser.Call]bool, signatureErrors map[*amlparser.Call]int, attributed map[string]bool, internal map[int]string) (result []Diagnostic) {
+	drop := map[int]bool{}
+	for call, i := range signatureErrors {
+		if argErrors[call] {
+			drop[i] = true
+		}
+	}
+	for i, name := range internal {
+		if attributed[name] {
+			drop[i] = true
+		}
+	}
+	for i, d := range diags {
+		if !drop[i] {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func newDiagnostic(err cueerrors.Error, src *source) Diagnostic {
+	path := strings.Join(err.Path(), ".")
+	result := Diagnostic{
+		Path:     path,
+		Severity: SeverityError,
+		Message:  strings.TrimPrefix(cueerrors.String(err), path+": "),
+	}
+
+	pos := position(err)
+	if pos.IsValid() {
+		setStart(&result, pos)
+	}
+
+	if e, ok := err.(interface{ End() token.Pos }); ok && e.End().IsValid() {
+		setEnd(&result, e.End())
+	} else if end, ok := src.end(pos); ok {
+		setEnd(&result, end)
+	}
+
+	return result
+}
+
+func setStart(d *Diagnostic, pos token.Pos) {
+	p := pos.Position()
+	d.Filename = p.Filename
+	d.Line = p.Line
+	d.Column = p.Column
+}
+
+func setEnd(d *Diagnostic, pos token.Pos) {
+	p := pos.Position()
+	d.EndLine = p.Line
+	d.EndColumn = p.Column
+}
+
+// position returns the primary position of err, preferring positions in the
+// user's files over positions in the schema.
+func position(err cueerrors.Error) (result token.Pos) {
+	for _, pos := range positions(err) {
+		if !pos.IsValid() {
+			continue
+		}
+		if !cue.IsInternal(pos) {
+			return pos
+		}
+		if !result.IsValid() {
+			result = pos
+		}
+	}
+	return result
+}
+
+func positions(err cueerrors.Error) []token.Pos {
+	return append([]token.Pos{err.Position()}, cueerrors.Positions(err)...)
+}
+
+type callErrorKind int
+
+const (
+	notCallError callErrorKind = iota
+	argumentError
+	signatureError
+	implementationError
+)
+
+var errorInCall = regexp.MustCompile(`^error in call to [^:]*: `)
+
+// callDiagnostic returns the diagnostic for err. If err is caused by the
+// _args or out fields of a desugared function call the diagnostic describes
+// the problem in terms of the call, for example
+// "std.split: argument 2 must be string, got int".
+//
+// Errors in the arguments carry the positions of the arguments as written.
+// Errors raised by the implementation of a std function only carry positions
+// in std.cue, so these are tied to the call to that function in the field
+// they are reported at.
+func callDiagnostic(err cueerrors.Error, src *source) (Diagnostic, *amlparser.Call, callErrorKind) {
+	var (
+		d     = newDiagnostic(err, src)
+		calls = src.calls
+	)
+
+	var (
+		path     = err.Path()
+		inArgs   = len(path) > 0 && path[0] == "_args"
+		stdName  string
+		call     *amlparser.Call
+		callSize = -1
+	)
+
+	for _, pos := range positions(err) {
+		if name, ok := std.Library.FunctionAt(pos); ok && stdName == "" {
+			stdName = name
+		}
+		for _, c := range calls {
+			if size := c.Rparen.Offset() - c.Pos().Offset(); c.Contains(pos) && (callSize == -1 || size < callSize) {
+				call, callSize = c, size
+			}
+		}
+	}
+
+	if call == nil && stdName != "" && onlyInStd(err) {
+		if inArgs && len(path) > 1 {
+			// The path is that of the arguments of an enclosing call
+			if call = callInArgs(calls, path[1:], "std."+stdName); call != nil {
+				inArgs = false
+			}
+		} else if !inArgs {
+			call = callInField(calls, path, "std."+stdName)
+		}
+	}
+
+	if call == nil || (!inArgs && stdName == "") {
+		return d, nil, notCallError
+	}
+
+	d.Path = strings.Join(call.Path, ".")
+	setStart(&d, call.Pos())
+	setEnd(&d, call.End())
+
+	switch {
+	case inArgs && len(path) > 1:
+		i, _ := strconv.Atoi(path[1])
+		arg := fmt.Sprintf("argument %d", i+1)
+		for _, sel := range path[2:] {
+			if _, err := strconv.Atoi(sel); err == nil {
+				arg += "[" + sel + "]"
+			} else {
+				arg += "." + sel
+			}
+		}
+		if i < len(call.Args) {
+			setStart(&d, call.Args[i].Pos())
+			setEnd(&d, call.Args[i].End())
+		}
+		if expected, got, ok := mismatchedTypes(err); ok {
+			d.Message = fmt.Sprintf("%s: %s must be %s, got %s", call.Name, arg, expected, got)
+		} else {
+			d.Message = fmt.Sprintf("%s: %s: %s", call.Name, arg, d.Message)
+		}
+		return d, call, argumentError
+	case inArgs:
+		d.Message = fmt.Sprintf("%s: invalid number or type of arguments (%d given)", call.Name, len(call.Args))
+		return d, call, signatureError
+	default:
+		if msg, ok := failure(err); ok {
+			d.Message = fmt.Sprintf("%s: %s", call.Name, msg)
+			return d, call, implementationError
+		}
+		d.Message = fmt.Sprintf("%s: %s", call.Name, errorInCall.ReplaceAllString(d.Message, ""))
+		return d, call, implementationError
+	}
+}
+
+// callInField returns the call to the function name in the innermost field
+// enclosing path. If the field has several such calls the first is returned.
+func callInField(calls []*amlparser.Call, path []string, name string) (result *amlparser.Call) {
+	for _, c := range calls {
+		if c.Name == name && hasPrefix(path, c.Path) && (result == nil || len(c.Path) > len(result.Path)) {
+			result = c
+		}
+	}
+	return result
+}
+
+// callInArgs returns the call to the function name found in the argument of
+// an enclosing call that path refers to, for example 0.1 for the second
+// element of a list passed as the first argument.
+func callInArgs(calls []*amlparser.Call, path []string, name string) *amlparser.Call {
+	for _, outer := range calls {
+		node := argAt(outer, path)
+		if node == nil {
+			continue
+		}
+		for _, c := range calls {
+			if c.Name == name && (c.Expr == node || within(c.Pos(), node)) {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// argAt returns the innermost node of the arguments of call that path refers
+// to.
+func argAt(call *amlparser.Call, path []string) ast.Node {
+	i, err := strconv.Atoi(path[0])
+	if err != nil || i >= len(call.Args) {
+		return nil
+	}
+	node := call.Args[i]
+	for _, sel := range path[1:] {
+		switch n := node.(type) {
+		case *ast.ListLit:
+			i, err := strconv.Atoi(sel)
+			if err != nil || i >= len(n.Elts) {
+				return node
+			}
+			node = n.Elts[i]
+		case *ast.StructLit:
+			next := node
+			for _, elt := range n.Elts {
+				if f, ok := elt.(*ast.Field); ok {
+					if label, _, _ := ast.LabelName(f.Label); label == sel {
+						next = f.Value
+					}
+				}
+			}
+			if next == node {
+				return node
+			}
+			node = next
+		default:
+			return node
+		}
+	}
+	return node
+}
+
+func within(pos token.Pos, node ast.Node) bool {
+	return pos.IsValid() && node.Pos().IsValid() && pos.Filename() == node.Pos().Filename() &&
+		node.Pos().Offset() <= pos.Offset() && pos.Offset() < node.End().Offset()
+}
+
+func hasPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stdInternalError returns the name of the std function err is raised in if
+// err refers to the arguments of that function but carries no position in the
+// user's files, such as the summary of an empty disjunction of signatures.
+func stdInternalError(err cueerrors.Error) (string, bool) {
+	if path := err.Path(); len(path) == 0 || path[0] != "_args" || inUserFile(err) {
+		return "", false
+	}
+	for _, pos := range positions(err) {
+		if name, ok := std.Library.FunctionAt(pos); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// onlyInStd reports whether all positions of err are in std.cue, which is the
+// case for errors raised by the implementation of a std function.
+func onlyInStd(err cueerrors.Error) bool {
+	for _, pos := range positions(err) {
+		if pos.IsValid() && pos.Filename() != std.Filename {
+			return false
+		}
+	}
+	return true
+}
+
+// inUserFile reports whether any position of err is in the user's files
+// rather than in std.cue or the schema.
+func inUserFile(err cueerrors.Error) bool {
+	for _, pos := range positions(err) {
+		if pos.IsValid() && pos.Filename() != std.Filename && !cue.IsInternal(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// failure returns the message of an error raised by a std function by
+// unifying the message with null, such as when a file read is missing.
+func failure(err cueerrors.Error) (string, bool) {
+	format, args := err.Msg()
+	if format != "conflicting values %s and %s (mismatched types %s and %s)" || len(args) != 4 {
+		return "", false
+	}
+	msg, other := fmt.Sprint(args[0]), fmt.Sprint(args[1])
+	if msg == "null" {
+		msg, other = other, msg
+	}
+	if other != "null" {
+		return "", false
+	}
+	msg, uerr := literal.Unquote(msg)
+	return msg, uerr == nil
+}
+
+// mismatchedTypes returns the expected and actual type of a conflict between
+// a type, such as string, and a value of another type.
+func mismatchedTypes(err cueerrors.Error) (expected, got string, ok bool) {
+	format, args := err.Msg()
+	if format != "conflicting values %s and %s (mismatched types %s and %s)" || len(args) != 4 {
+		return "", "", false
+	}
+	v1, v2, k1, k2 := fmt.Sprint(args[0]), fmt.Sprint(args[1]), fmt.Sprint(args[2]), fmt.Sprint(args[3])
+	switch {
+	case v1 == k1:
+		return v1, k2, true
+	case v2 == k2:
+		return v2, k1, true
+	}
+	return "", "", false
+}
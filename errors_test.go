@@ -0,0 +1,311 @@
+package aml
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeError(t *testing.T, input string) *Error {
+	t.Helper()
+	err := Unmarshal([]byte(input), &map[string]any{})
+	var amlErr *Error
+	if !errors.As(err, &amlErr) {
+		t.Fatalf("expected *Error, got %T: %v", err, err)
+	}
+	return amlErr
+}
+
+func TestErrorParse(t *testing.T) {
+	err := decodeError(t, `containers: web: {
+	image: "nginx" "foo"
+}`)
+	assert.Equal(t, Diagnostic{
+		Filename: "Acornfile",
+		Line:     2,
+		Column:   17,
+		Severity: SeverityError,
+		Message:  "missing ',' in struct literal",
+	}, err.Diagnostics[0])
+}
+
+func TestErrorStdReference(t *testing.T) {
+	err := decodeError(t, `
+x: std.toUppr("a")
+y: std.toLowr("a")
+`)
+	assert.Len(t, err.Diagnostics, 2)
+	assert.Equal(t, Diagnostic{
+		Filename:  "Acornfile",
+		Line:      2,
+		Column:    4,
+		EndLine:   2,
+		EndColumn: 14,
+		Severity:  SeverityError,
+		Message:   "invalid reference to std.toUppr, closest matches [toUpper toYAML trim]",
+	}, err.Diagnostics[0])
+	assert.Equal(t, 3, err.Diagnostics[1].Line)
+}
+
+func TestErrorSchema(t *testing.T) {
+	err := decodeError(t, `containers: web: {
+	image: 1
+}`)
+	assert.Len(t, err.Diagnostics, 1)
+	assert.Equal(t, "containers.web.image", err.Diagnostics[0].Path)
+	assert.Equal(t, "Acornfile", err.Diagnostics[0].Filename)
+	assert.Equal(t, 2, err.Diagnostics[0].Line)
+	assert.Equal(t, 9, err.Diagnostics[0].Column)
+	assert.Equal(t, 2, err.Diagnostics[0].EndLine)
+	assert.Equal(t, 10, err.Diagnostics[0].EndColumn)
+
+	data, jsonErr := json.Marshal(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+	assert.Contains(t, string(data), `"path":"containers.web.image"`)
+}
+
+func TestErrorArgs(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`
+args: {
+	image: string
+	replicas: 1
+}
+
+containers: web: {
+	image: args.image
+	scale: args.replicas
+}
+`))
+	err := d.Decode(&map[string]any{}, Options{Args: map[string]any{"replica": 2}})
+	var amlErr *Error
+	if !errors.As(err, &amlErr) {
+		t.Fatalf("expected *Error, got %T: %v", err, err)
+	}
+	assert.Equal(t, []Diagnostic{
+		{Path: "args.image", Severity: SeverityError, Message: "missing required string arg"},
+		{Path: "args.replica", Severity: SeverityError, Message: `unknown arg, did you mean "replicas"?`},
+	}, amlErr.Diagnostics)
+}
+
+func TestErrorCalls(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+		column  int
+	}{
+		{
+			input:   `containers: web: image: std.split("a", x), x: 1`,
+			message: "std.split: argument 2 must be string, got int",
+			column:  40,
+		},
+		{
+			input:   `containers: web: image: std.join(["a", x], ","), x: 1`,
+			message: "std.join: argument 1[1] must be string, got int",
+			column:  34,
+		},
+		{
+			input:   `containers: web: image: std.toUpper(std.trim(x)), x: 1`,
+			message: "std.trim: argument 1 must be string, got int",
+			column:  46,
+		},
+		{
+			input:   `containers: web: image: std.atoi("abc")`,
+			message: `std.atoi: strconv.Atoi: parsing "abc": invalid syntax`,
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.split("a")`,
+			message: "std.split: expected 2 or 3 arguments, got 1",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.trim("a", "b")`,
+			message: "std.trim: expected 1 argument, got 2",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.split("a", 1)`,
+			message: "std.split: argument 2 must be string, got int",
+			column:  40,
+		},
+		{
+			input:   `containers: web: image: std.replace("a", "b", "c", "d")`,
+			message: "std.replace: argument 4 must be int, got string",
+			column:  52,
+		},
+		{
+			input:   `containers: web: image: std.toUpper(std.trim(1))`,
+			message: "std.trim: argument 1 must be string, got int",
+			column:  46,
+		},
+		{
+			input:   `containers: web: image: std.find("nginx", "(")`,
+			message: "std.find: error parsing regexp: missing closing ): `(`",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.format()`,
+			message: "std.format: expected at least 1 argument, got 0",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.format(x, 1, 2), x: 1`,
+			message: "std.format: argument 1 must be string, got int",
+			column:  36,
+		},
+		{
+			input:   `containers: web: image: std.format("%d", "x")`,
+			message: "std.format: argument 2: %d requires int, got string",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.format("%s", 1, 2)`,
+			message: "std.format: too many arguments, the format uses 1",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.parseIP("1.2.3"), containers: web: dirs: "/a": std.parseIP("::1")`,
+			message: "std.parseIP: invalid IP address \"1.2.3\"",
+			column:  25,
+		},
+		{
+			input:   `containers: web: env: a: std.cidrHost("10.0.0.0/24", 1), containers: web: image: std.cidrHost("10.0.0.0/24", 300)`,
+			message: "std.cidrHost: prefix of 24 bits has no host number 300",
+			column:  82,
+		},
+		{
+			input:   `containers: web: image: std.urlParse("http://[::1")`,
+			message: "std.urlParse: parse \"http://[::1\": missing ']' in host",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.join(std.regexSplit("nginx", "("), "")`,
+			message: "std.regexSplit: error parsing regexp: missing closing ): `(`",
+			column:  34,
+		},
+		{
+			input:   `containers: web: image: std.join([std.dnsName("web", 1)], "")`,
+			message: "std.dnsName: maximum length must be at least 10, got 1",
+			column:  35,
+		},
+		{
+			input:   `containers: web: image: std.uuidv5("ns", "web")`,
+			message: "std.uuidv5: invalid UUID namespace \"ns\"",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.fromTOML("a = 1\na = 2")`,
+			message: "std.fromTOML: key a is already defined",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.fromTOML("[[a]]\n[a]")`,
+			message: "std.fromTOML: key a should be a table, not a array table",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.fromTOML("b = {x = 1}\nb.y = 2")`,
+			message: "std.fromTOML: expected b to be a table, not a value",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.fromTOML("a = 1\nb = ")`,
+			message: "std.fromTOML: line 2, column 5: expected value, not eof",
+			column:  25,
+		},
+		{
+			input:   `containers: web: image: std.contains({}, 1)`,
+			message: "std.contains: argument 2 must be string, got int",
+			column:  42,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			err := decodeError(t, tt.input)
+			assert.Len(t, err.Diagnostics, 1)
+			assert.Equal(t, "containers.web.image", err.Diagnostics[0].Path)
+			assert.Equal(t, tt.message, err.Diagnostics[0].Message)
+			assert.Equal(t, 1, err.Diagnostics[0].Line)
+			assert.Equal(t, tt.column, err.Diagnostics[0].Column)
+		})
+	}
+}
+
+func TestErrorCallsReferences(t *testing.T) {
+	tests := []struct {
+		input   string
+		path    string
+		message string
+		column  int
+	}{
+		{
+			input:   "args: x: \"abc\"\ncontainers: web: scale: std.atoi(args.x)",
+			path:    "containers.web.scale",
+			message: `std.atoi: strconv.Atoi: parsing "abc": invalid syntax`,
+			column:  25,
+		},
+		{
+			input:   "args: x: 2\ncontainers: web: image: std.split(\"a\", args.x)",
+			path:    "containers.web.image",
+			message: "std.split: argument 2 must be string, got int",
+			column:  40,
+		},
+		{
+			input:   "args: x: \"(\"\ncontainers: web: image: std.join(std.regexSplit(\"nginx\", args.x), \"\")",
+			path:    "containers.web.image",
+			message: "std.regexSplit: error parsing regexp: missing closing ): `(`",
+			column:  34,
+		},
+		{
+			input:   "args: {x: \"1\", y: \"b\"}\ncontainers: a: scale: std.atoi(args.x)\ncontainers: b: scale: std.atoi(args.y)",
+			path:    "containers.b.scale",
+			message: `std.atoi: strconv.Atoi: parsing "b": invalid syntax`,
+			column:  23,
+		},
+		{
+			input:   "args: f: \"env.yaml\"\ncontainers: web: env: std.readYAML(args.f)",
+			path:    "containers.web.env",
+			message: "std.readYAML: file not found: env.yaml",
+			column:  23,
+		},
+		{
+			input:   "args: f: \"app.json\"\ncontainers: web: image: std.readJSON(args.f).image",
+			path:    "containers.web.image",
+			message: "std.readJSON: file not found: app.json",
+			column:  25,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			err := decodeError(t, tt.input)
+			assert.Len(t, err.Diagnostics, 1)
+			assert.Equal(t, tt.path, err.Diagnostics[0].Path)
+			assert.Equal(t, tt.message, err.Diagnostics[0].Message)
+			assert.Equal(t, tt.column, err.Diagnostics[0].Column)
+		})
+	}
+}
+
+func TestErrorCallsSameFunction(t *testing.T) {
+	err := decodeError(t, `containers: a: scale: std.atoi("1"), containers: b: scale: std.atoi("x")`)
+	assert.Len(t, err.Diagnostics, 1)
+	assert.Equal(t, "containers.b.scale", err.Diagnostics[0].Path)
+	assert.Equal(t, "std.atoi: strconv.Atoi: parsing \"x\": invalid syntax", err.Diagnostics[0].Message)
+	assert.Equal(t, 60, err.Diagnostics[0].Column)
+}
+
+func TestErrorNotInCall(t *testing.T) {
+	err := decodeError(t, `containers: web: image: std.toUpper("a") + 1`)
+	assert.Len(t, err.Diagnostics, 1)
+	assert.Equal(t, "invalid operands \"A\" and 1 to '+' (type string and int)", err.Diagnostics[0].Message)
+	assert.Equal(t, 44, err.Diagnostics[0].Column)
+
+	err = decodeError(t, `containers: web: scale: std.toUpper("a")`)
+	assert.Len(t, err.Diagnostics, 1)
+	assert.NotContains(t, err.Diagnostics[0].Message, "std.toUpper")
+}
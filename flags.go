@@ -0,0 +1,243 @@
+package aml
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/acorn-io/aml/pkg/definition"
+)
+
+// ProfileFlag is the name of the flag selecting profiles.
+const ProfileFlag = "profile"
+
+// Flags exposes the args and profiles of a ParamSpec as command line flags.
+// Each arg becomes a flag of the same name and the profiles are selected with
+// --profile, which skips a profile ending in ? if it does not exist. Values
+// are converted as described for ParseArgs, bool flags may be given without a
+// value and array flags may be repeated, each occurrence adding to the list.
+type Flags struct {
+	FlagSet *flag.FlagSet
+
+	spec     *definition.ParamSpec
+	args     map[string]*argFlag
+	profiles *listFlag
+}
+
+// NewFlags returns the Flags for spec. The usage of the FlagSet prints the
+// text returned by Usage. An arg named profile replaces the --profile flag.
+func NewFlags(name string, spec *definition.ParamSpec) *Flags {
+	if spec == nil {
+		spec = &definition.ParamSpec{}
+	}
+	f := &Flags{
+		FlagSet:  flag.NewFlagSet(name, flag.ContinueOnError),
+		spec:     spec,
+		args:     map[string]*argFlag{},
+		profiles: &listFlag{},
+	}
+	for _, param := range spec.Params {
+		arg := &argFlag{param: param}
+		f.args[param.Name] = arg
+		f.FlagSet.Var(arg, param.Name, param.Description)
+	}
+	if len(spec.Profiles) > 0 && f.args[ProfileFlag] == nil {
+		f.FlagSet.Var(f.profiles, ProfileFlag, "Profiles to apply")
+	}
+	f.FlagSet.Usage = func() {
+		fmt.Fprint(f.FlagSet.Output(), f.Usage())
+	}
+	return f
+}
+
+// Parse parses the command line arguments, which must not include the
+// command name.
+func (f *Flags) Parse(arguments []string) error {
+	return f.FlagSet.Parse(arguments)
+}
+
+// Options returns the args and profiles set by the parsed flags. Flags that
+// were not given are left out so their defaults apply. If any value is
+// invalid an *Error is returned describing each of them.
+func (f *Flags) Options() (Options, error) {
+	var (
+		opts        = Options{Args: map[string]any{}}
+		diagnostics []Diagnostic
+	)
+
+	var names []string
+	for name := range f.args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		arg := f.args[name]
+		if len(arg.values) == 0 {
+			continue
+		}
+		value, err := arg.value()
+		if err != nil {
+			diagnostics = append(diagnostics, argDiagnostic(name, err))
+			continue
+		}
+		opts.Args[name] = value
+	}
+
+	valid := map[string]bool{}
+	var quoted []string
+	for _, profile := range f.spec.Profiles {
+		valid[profile.Name] = true
+		quoted = append(quoted, strconv.Quote(profile.Name))
+	}
+	for _, profile := range f.profiles.values {
+		// A profile ending in ? is optional and applied only if it exists
+		name := strings.TrimSuffix(profile, "?")
+		if !valid[name] {
+			if name != profile {
+				continue
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Path:     "profiles",
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("unknown profile %q, expected one of %s", profile, strings.Join(quoted, ", ")),
+			})
+			continue
+		}
+		opts.Profiles = append(opts.Profiles, profile)
+	}
+
+	if len(diagnostics) > 0 {
+		return Options{}, argsError(diagnostics)
+	}
+	return opts, nil
+}
+
+// Usage returns the help text for the flags, listing the args followed by the
+// profiles.
+func (f *Flags) Usage() string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Usage of %s:\n", f.FlagSet.Name())
+
+	w := tabwriter.NewWriter(buf, 0, 0, 3, ' ', 0)
+	if len(f.spec.Params) > 0 {
+		fmt.Fprint(w, "\nArgs:\n")
+		for _, param := range f.spec.Params {
+			flagName := "--" + param.Name
+			if kind := flagKind(param); kind != "" {
+				flagName += " " + kind
+			}
+			lines := strings.Split(param.Description, "\n")
+			if param.Default != nil {
+				def, _ := json.Marshal(param.Default)
+				lines[len(lines)-1] = strings.TrimSpace(lines[len(lines)-1] + " (default " + string(def) + ")")
+			} else if param.Required {
+				lines[len(lines)-1] = strings.TrimSpace(lines[len(lines)-1] + " (required)")
+			}
+			for i, line := range lines {
+				if i > 0 {
+					flagName = ""
+				}
+				fmt.Fprintf(w, "  %s\t%s\n", flagName, line)
+			}
+		}
+	}
+	if len(f.spec.Profiles) > 0 && f.args[ProfileFlag] == nil {
+		fmt.Fprintf(w, "\nProfiles (--%s name, may be repeated):\n", ProfileFlag)
+		for _, profile := range f.spec.Profiles {
+			fmt.Fprintf(w, "  %s\t%s\n", profile.Name, strings.ReplaceAll(profile.Description, "\n", " "))
+		}
+	}
+	_ = w.Flush()
+
+	// Drop the padding left by params and profiles without a description
+	lines := strings.Split(buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// flagKind describes the value expected by the flag of param.
+func flagKind(param definition.Param) string {
+	switch param.Type {
+	case "bool":
+		return ""
+	case "enum":
+		return strings.Join(param.Options, "|")
+	case "array":
+		return "list"
+	case "":
+		return "value"
+	}
+	return param.Type
+}
+
+// argFlag is the flag.Value of an arg, recording the raw values given.
+type argFlag struct {
+	param  definition.Param
+	values []string
+}
+
+func (a *argFlag) String() string {
+	if a == nil || len(a.values) == 0 {
+		return ""
+	}
+	return strings.Join(a.values, ",")
+}
+
+func (a *argFlag) Set(s string) error {
+	if a.param.Type == "array" {
+		a.values = append(a.values, s)
+	} else {
+		a.values = []string{s}
+	}
+	return nil
+}
+
+func (a *argFlag) IsBoolFlag() bool {
+	return a.param.Type == "bool"
+}
+
+// value converts the values of the flag. Each value of a repeated array flag
+// is converted on its own and the results are concatenated.
+func (a *argFlag) value() (any, error) {
+	if a.param.Type != "array" {
+		return parseArg(a.param, a.values[0])
+	}
+	result := []any{}
+	for _, s := range a.values {
+		value, err := parseArg(a.param, s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value.([]any)...)
+	}
+	return result, nil
+}
+
+// listFlag is a flag.Value collecting comma separated and repeated values.
+type listFlag struct {
+	values []string
+}
+
+func (l *listFlag) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(l.values, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			l.values = append(l.values, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package aml
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testFlagsAcornfile = `
+args: {
+	// Number of replicas
+	replicas: 1
+	// Enable debug logging
+	debug: false
+	// Deployment mode
+	mode: "dev" | "prod"
+	// Extra tags
+	tags: ["a"]
+}
+
+profiles: {
+	// Production settings
+	prod: {
+		replicas: 3
+	}
+}
+
+containers: web: {
+	image: "nginx"
+	scale: args.replicas
+}
+`
+
+func TestFlags(t *testing.T) {
+	d := NewDecoder(strings.NewReader(testFlagsAcornfile))
+	spec, err := d.Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := NewFlags("deploy", spec)
+	err = flags.Parse([]string{"--debug", "--tags", "x,y", "--tags=z", "--mode", "prod", "--profile", "prod", "rest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"rest"}, flags.FlagSet.Args())
+
+	opts, err := flags.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, Options{
+		Args: map[string]any{
+			"debug": true,
+			"mode":  "prod",
+			"tags":  []any{"x", "y", "z"},
+		},
+		Profiles: []string{"prod"},
+	}, opts)
+
+	computed, err := d.ComputedArgs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, computed["replicas"])
+}
+
+func TestFlagsOptionalProfile(t *testing.T) {
+	d := NewDecoder(strings.NewReader(testFlagsAcornfile))
+	spec, err := d.Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := NewFlags("deploy", spec)
+	if err := flags.Parse([]string{"--profile", "prod?", "--profile", "staging?"}); err != nil {
+		t.Fatal(err)
+	}
+	opts, err := flags.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"prod?"}, opts.Profiles)
+
+	computed, err := d.ComputedArgs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3, computed["replicas"])
+}
+
+func TestFlagsErrors(t *testing.T) {
+	spec, err := NewDecoder(strings.NewReader(testFlagsAcornfile)).Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := NewFlags("deploy", spec)
+	if err := flags.Parse([]string{"--replicas=x", "--mode=test", "--profile=staging"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = flags.Options()
+	var amlErr *Error
+	if !errors.As(err, &amlErr) {
+		t.Fatalf("expected *Error, got %T: %v", err, err)
+	}
+	assert.Len(t, amlErr.Diagnostics, 3)
+	assert.Equal(t, `invalid value "test", expected one of "dev", "prod"`, amlErr.Diagnostics[0].Message)
+	assert.Equal(t, `invalid value "x", expected int`, amlErr.Diagnostics[1].Message)
+	assert.Equal(t, `unknown profile "staging", expected one of "prod"`, amlErr.Diagnostics[2].Message)
+
+	flags = NewFlags("deploy", spec)
+	flags.FlagSet.SetOutput(io.Discard)
+	assert.Error(t, flags.Parse([]string{"--unknown=1"}))
+}
+
+func TestFlagsUsage(t *testing.T) {
+	spec, err := NewDecoder(strings.NewReader(testFlagsAcornfile)).Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `Usage of deploy:
+
+Args:
+  --replicas int    Number of replicas (default 1)
+  --debug           Enable debug logging (default false)
+  --mode dev|prod   Deployment mode (default "dev")
+  --tags list       Extra tags (default ["a"])
+
+Profiles (--profile name, may be repeated):
+  prod   Production settings
+`, NewFlags("deploy", spec).Usage())
+}
@@ -7,6 +7,7 @@ require (
 	github.com/acorn-io/baaah v0.0.0-20230129022613-803520949ab8
 	github.com/agnivade/levenshtein v1.1.1
 	github.com/cockroachdb/apd/v2 v2.0.2
+	github.com/pelletier/go-toml/v2 v2.0.6
 	github.com/stretchr/testify v1.8.1
 	sigs.k8s.io/yaml v1.3.0
 )
@@ -11,6 +11,15 @@ func Unmarshal(data []byte, v any) error {
 	return NewDecoder(bytes.NewBuffer(data)).Decode(v)
 }
 
+// Marshal returns the formatted AML encoding of v. See Encoder for details.
+func Marshal(v any) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // ParseInt parses a number string to int following the
 // same number syntax that AML supports.
 func ParseInt(numString string) (int64, error) {
@@ -0,0 +1,105 @@
+package aml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testApp struct {
+	Containers map[string]testContainer `json:"containers,omitempty" doc:"Containers to run"`
+	Labels     map[string]string        `json:"labels,omitempty"`
+}
+
+type testContainer struct {
+	Image string            `json:"image,omitempty"`
+	Scale int               `json:"scale,omitempty"`
+	Env   map[string]string `json:"env,omitempty"`
+	Files map[string]string `json:"files,omitempty"`
+	Ports []string          `json:"ports,omitempty"`
+}
+
+func TestMarshal(t *testing.T) {
+	app := testApp{
+		Containers: map[string]testContainer{
+			"web": {
+				Image: "nginx",
+				Scale: 2,
+				Env: map[string]string{
+					"FOO":     "bar",
+					"foo-bar": "baz",
+				},
+				Files: map[string]string{
+					"/etc/nginx.conf": "server {\n\tlisten 80;\n}\n",
+				},
+				Ports: []string{"80/http"},
+			},
+		},
+		Labels: map[string]string{
+			"import": "\\(x)",
+		},
+	}
+
+	data, err := Marshal(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `// Containers to run
+containers: {
+	web: {
+		image: "nginx"
+		scale: 2
+		env: {
+			FOO:       "bar"
+			"foo-bar": "baz"
+		}
+		files: {
+			"/etc/nginx.conf": """
+				server {
+				\tlisten 80;
+				}
+
+				"""
+		}
+		ports: ["80/http"]
+	}
+}
+labels: {
+	"import": "\\(x)"
+}
+`, string(data))
+
+	var result testApp
+	if err := Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, app, result)
+}
+
+func TestMarshalValues(t *testing.T) {
+	data, err := Marshal(map[string]any{
+		"b":     true,
+		"f":     3.0,
+		"i":     -4,
+		"n":     nil,
+		"bytes": []byte("hi"),
+		"list":  []any{1, "two", 3.5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `b:     true
+bytes: 'hi'
+f:     3.0
+i:     -4
+list: [1, "two", 3.5]
+n: null
+`, string(data))
+}
+
+func TestMarshalNotStruct(t *testing.T) {
+	_, err := Marshal([]string{"a"})
+	assert.Error(t, err)
+}
@@ -1,7 +1,6 @@
 package amlparser
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 
@@ -15,25 +14,28 @@ import (
 )
 
 type needStd struct {
-	errs      []error
-	needed    bool
-	functions map[string]bool
-}
-
-func (n *needStd) Needed() bool {
-	return n.needed
+	errs       []error
+	used       []string
+	functions  map[string]bool
+	signatures map[string][]std.Signature
 }
 
 func (n *needStd) Err() error {
 	return merr.NewErrors(n.errs...)
 }
 
-func bestFunction(name string, functions map[string]bool) []string {
+// ClosestMatches returns up to three of the candidates closest to name by
+// edit distance, ignoring case. Candidates further than maxDistance are left
+// out unless maxDistance is negative.
+func ClosestMatches(name string, candidates map[string]bool, maxDistance int) []string {
 	var (
 		match = map[int]string{}
 	)
-	for _, f := range typed.SortedKeys(functions) {
+	for _, f := range typed.SortedKeys(candidates) {
 		d := levenshtein.ComputeDistance(strings.ToLower(name), strings.ToLower(f))
+		if maxDistance >= 0 && d > maxDistance {
+			continue
+		}
 		match[d] = f
 	}
 
@@ -46,14 +48,15 @@ func bestFunction(name string, functions map[string]bool) []string {
 
 func (n *needStd) Walk(node ast.Node) bool {
 	if _, ok := node.(*ast.Package); ok {
-		n.errs = append(n.errs, fmt.Errorf("package keyword is not supported"))
+		n.errs = append(n.errs, newError(node, "package keyword is not supported"))
 	}
 	if sel, ok := node.(*ast.SelectorExpr); ok {
 		if i, ok := sel.X.(*ast.Ident); ok && i.Name == "std" {
-			n.needed = true
 			if i, ok := sel.Sel.(*ast.Ident); ok {
-				if !n.functions[i.Name] {
-					n.errs = append(n.errs, fmt.Errorf("invalid reference to std.%s, closest matches %s %v", i.Name, bestFunction(i.Name, n.functions), sel.Pos()))
+				if n.functions[i.Name] {
+					n.used = append(n.used, i.Name)
+				} else {
+					n.errs = append(n.errs, newError(sel, "invalid reference to std.%s, closest matches %s", i.Name, ClosestMatches(i.Name, n.functions, -1)))
 				}
 			}
 		}
@@ -168,7 +171,7 @@ func (a *argsOptional) Walk(node ast.Node) bool {
 
 		for _, e := range s.Elts {
 			if _, ok := e.(*ast.Comprehension); ok {
-				a.errs = append(a.errs, errors.New("comprehension (if) should not be used inside the args and profiles fields"))
+				a.errs = append(a.errs, newError(e, "comprehension (if) should not be used inside the args and profiles fields"))
 				return false
 			}
 			f, ok := e.(*ast.Field)
@@ -193,7 +196,7 @@ func (a *argsOptional) walkFields(f *ast.Field) bool {
 
 	for _, e := range s.Elts {
 		if _, ok := e.(*ast.Comprehension); ok {
-			a.errs = append(a.errs, errors.New("comprehension (if) should not be used inside the args and profiles fields"))
+			a.errs = append(a.errs, newError(e, "comprehension (if) should not be used inside the args and profiles fields"))
 			return false
 		}
 		f, ok := e.(*ast.Field)
@@ -231,19 +234,26 @@ func ParseFile(name string, src interface{}) (f *ast.File, err error) {
 		return nil, err
 	}
 	if len(file.Imports) > 0 {
-		return nil, fmt.Errorf("import keyword is not supported")
+		return nil, newError(file.Imports[0], "import keyword is not supported")
 	}
 	args := argsOptional{}
-	needStd := needStd{functions: std.Library.Functions}
+	needStd := needStd{
+		functions:  std.Library.Functions,
+		signatures: std.Library.Signatures,
+	}
 	for _, decl := range file.Decls {
 		ast.Walk(decl, args.Walk, nil)
 		ast.Walk(decl, needStd.Walk, nil)
+		for _, call := range Calls(decl) {
+			needStd.checkCall(call)
+		}
 	}
 
-	if needStd.Needed() {
-		file.Imports = std.Library.Imports
-		file.Decls = append(file.Decls, std.Library.Decls...)
-		file.Unresolved = append(file.Unresolved, std.Library.Unresolved...)
+	if len(needStd.used) > 0 {
+		imports, decls, unresolved := std.Library.Select(needStd.used...)
+		file.Imports = imports
+		file.Decls = append(file.Decls, decls...)
+		file.Unresolved = append(file.Unresolved, unresolved...)
 	}
 	return file, merr.NewErrors(args.Err(), needStd.Err())
 }
@@ -0,0 +1,112 @@
+package amlparser
+
+import (
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+	"github.com/acorn-io/aml/pkg/astinternal"
+)
+
+// Call is a function call as written in the source. The parser desugars
+// fun(args...) into (fun & {_args: [args...]}).out, so evaluation errors
+// refer to _args and out rather than to the call.
+type Call struct {
+	// Expr is the desugared expression
+	Expr   ast.Expr
+	Name   string
+	Fun    ast.Expr
+	Args   []ast.Expr
+	Lparen token.Pos
+	Rparen token.Pos
+	// Path is the path of the field the call is found in
+	Path []string
+}
+
+func (c *Call) Pos() token.Pos {
+	return c.Fun.Pos()
+}
+
+func (c *Call) End() token.Pos {
+	return c.Rparen.Add(1)
+}
+
+// Contains reports whether pos is within the source range of the call.
+func (c *Call) Contains(pos token.Pos) bool {
+	return pos.IsValid() && pos.Filename() == c.Pos().Filename() &&
+		c.Pos().Offset() <= pos.Offset() && pos.Offset() <= c.Rparen.Offset()
+}
+
+// AsCall returns the call expr was desugared from. Desugared calls are
+// recognized by their positions: the out selector and the & operator are
+// both placed at the opening parenthesis and the wrapping parenthesis has no
+// position, which can not be the case for source written by the user.
+func AsCall(expr ast.Node) (*Call, bool) {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || !isIdent(sel.Sel, "out") {
+		return nil, false
+	}
+	paren, ok := sel.X.(*ast.ParenExpr)
+	if !ok || paren.Lparen.IsValid() {
+		return nil, false
+	}
+	bin, ok := paren.X.(*ast.BinaryExpr)
+	if !ok || bin.Op != token.AND {
+		return nil, false
+	}
+	s, ok := bin.Y.(*ast.StructLit)
+	if !ok || len(s.Elts) != 1 || !s.Lbrace.IsValid() || bin.OpPos != s.Lbrace || sel.Sel.Pos() != s.Lbrace {
+		return nil, false
+	}
+	f, ok := s.Elts[0].(*ast.Field)
+	if !ok || !isIdent(f.Label, "_args") {
+		return nil, false
+	}
+	args, ok := f.Value.(*ast.ListLit)
+	if !ok {
+		return nil, false
+	}
+	return &Call{
+		Expr:   sel,
+		Name:   astinternal.DebugStr(bin.X),
+		Fun:    bin.X,
+		Args:   args.Elts,
+		Lparen: s.Lbrace,
+		Rparen: s.Rbrace,
+	}, true
+}
+
+// Calls returns all desugared function calls found in node.
+func Calls(node ast.Node) []*Call {
+	return calls(node, nil)
+}
+
+func calls(node ast.Node, path []string) (result []*Call) {
+	ast.Walk(node, func(n ast.Node) bool {
+		if f, ok := n.(*ast.Field); ok {
+			name, _, _ := ast.LabelName(f.Label)
+			path = append(path, name)
+		}
+		call, ok := AsCall(n)
+		if !ok {
+			return true
+		}
+		// Do not descend into the desugared struct so the synthetic _args
+		// field does not show up in the path of nested calls.
+		call.Path = append([]string(nil), path...)
+		result = append(result, call)
+		result = append(result, calls(call.Fun, path)...)
+		for _, arg := range call.Args {
+			result = append(result, calls(arg, path)...)
+		}
+		return false
+	}, func(n ast.Node) {
+		if _, ok := n.(*ast.Field); ok {
+			path = path[:len(path)-1]
+		}
+	})
+	return result
+}
+
+func isIdent(node ast.Node, name string) bool {
+	i, ok := node.(*ast.Ident)
+	return ok && i.Name == name
+}
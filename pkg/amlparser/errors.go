@@ -0,0 +1,56 @@
+package amlparser
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+var _ errors.Error = (*Error)(nil)
+
+// Error is an AML specific parse error covering the source range of the
+// offending node.
+type Error struct {
+	pos, end token.Pos
+	path     []string
+	message  string
+}
+
+type span interface {
+	Pos() token.Pos
+	End() token.Pos
+}
+
+func newError(node span, format string, args ...any) *Error {
+	return &Error{
+		pos:     node.Pos(),
+		end:     node.End(),
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+func (e *Error) Position() token.Pos {
+	return e.pos
+}
+
+// End returns the position just after the offending node.
+func (e *Error) End() token.Pos {
+	return e.end
+}
+
+func (e *Error) InputPositions() []token.Pos {
+	return nil
+}
+
+func (e *Error) Path() []string {
+	return e.path
+}
+
+func (e *Error) Msg() (format string, args []interface{}) {
+	return "%s", []interface{}{e.message}
+}
@@ -0,0 +1,160 @@
+package amlparser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+	"github.com/acorn-io/aml/pkg/std"
+)
+
+// checkCall validates the number of arguments of a call to a std function and
+// the kind of any literal arguments against the function's signatures.
+func (n *needStd) checkCall(call *Call) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || !isIdent(sel.X, "std") {
+		return
+	}
+	name, _, _ := ast.LabelName(sel.Sel)
+	sigs, ok := n.signatures[name]
+	if !ok || len(sigs) == 0 {
+		return
+	}
+
+	var candidates []std.Signature
+	for _, sig := range sigs {
+		if sig.AcceptsCount(len(call.Args)) {
+			candidates = append(candidates, sig)
+		}
+	}
+	if len(candidates) == 0 {
+		err := newError(call, "%s: expected %s, got %d", call.Name, arities(sigs), len(call.Args))
+		err.path = call.Path
+		n.errs = append(n.errs, err)
+		return
+	}
+
+	// Narrow down the candidates one argument at a time, reporting the first
+	// argument no remaining signature accepts.
+	for i, arg := range call.Args {
+		var (
+			kind     = LiteralKind(arg)
+			accepted []std.Signature
+			expected std.Param
+		)
+		for _, sig := range candidates {
+			if sig.Param(i).Accepts(kind) {
+				accepted = append(accepted, sig)
+			}
+			expected = append(expected, sig.Param(i)...)
+		}
+		if len(accepted) == 0 {
+			err := newError(arg, "%s: argument %d must be %s, got %s", call.Name, i+1, unique(expected), kind)
+			err.path = call.Path
+			n.errs = append(n.errs, err)
+			return
+		}
+		candidates = accepted
+	}
+}
+
+// arities describes the number of arguments accepted, such as "2 or 3
+// arguments" or "at least 1 argument".
+func arities(sigs []std.Signature) string {
+	var (
+		seen    = map[int]bool{}
+		counts  []int
+		atLeast = -1
+	)
+	for _, sig := range sigs {
+		if sig.Variadic() {
+			if atLeast == -1 || len(sig)-1 < atLeast {
+				atLeast = len(sig) - 1
+			}
+		} else if !seen[len(sig)] {
+			seen[len(sig)] = true
+			counts = append(counts, len(sig))
+		}
+	}
+	sort.Ints(counts)
+
+	var (
+		strs []string
+		last int
+	)
+	for _, c := range counts {
+		if atLeast == -1 || c < atLeast {
+			strs = append(strs, fmt.Sprint(c))
+			last = c
+		}
+	}
+	if atLeast != -1 {
+		strs = append(strs, fmt.Sprintf("at least %d", atLeast))
+		last = atLeast
+	}
+
+	result := strs[len(strs)-1]
+	if len(strs) > 1 {
+		result = strings.Join(strs[:len(strs)-1], ", ") + " or " + result
+	}
+	if len(strs) == 1 && last == 1 {
+		return result + " argument"
+	}
+	return result + " arguments"
+}
+
+func unique(p std.Param) std.Param {
+	var (
+		seen   = map[string]bool{}
+		result std.Param
+	)
+	for _, k := range p {
+		if !seen[k] {
+			seen[k] = true
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// LiteralKind returns the kind of value expr evaluates to if it is a literal,
+// or "_" if the kind is not known until evaluation.
+func LiteralKind(expr ast.Expr) string {
+	switch v := expr.(type) {
+	case *ast.BasicLit:
+		switch v.Kind {
+		case token.STRING:
+			if strings.HasPrefix(strings.TrimLeft(v.Value, "#"), "'") {
+				return "bytes"
+			}
+			return "string"
+		case token.INT:
+			return "int"
+		case token.FLOAT:
+			return "float"
+		case token.TRUE, token.FALSE:
+			return "bool"
+		case token.NULL:
+			return "null"
+		}
+	case *ast.Interpolation:
+		if len(v.Elts) > 0 {
+			return LiteralKind(v.Elts[0])
+		}
+	case *ast.ListLit:
+		return "list"
+	case *ast.StructLit:
+		return "struct"
+	case *ast.UnaryExpr:
+		if v.Op == token.SUB || v.Op == token.ADD {
+			if kind := LiteralKind(v.X); kind == "int" || kind == "float" {
+				return kind
+			}
+		}
+	case *ast.ParenExpr:
+		return LiteralKind(v.X)
+	}
+	return "_"
+}
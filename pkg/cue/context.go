@@ -3,6 +3,7 @@ package cue
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/fs"
 	"sync"
 
@@ -24,8 +25,18 @@ type Context struct {
 	parseFile      ParserFunc
 	schemaPath     string
 	schemaTypeName string
+	schemaFiles    []File
+	resolver       Resolver
 }
 
+// Resolver is called with each value built from the files of a Context. It
+// returns the source of an additional file supplying values the evaluation was
+// missing, or nil if there is nothing to add.
+type Resolver func(value cue.Value) ([]byte, error)
+
+// maxResolves limits the number of times the files are rebuilt for a resolver.
+const maxResolves = 100
+
 type fsEntry struct {
 	prepend string
 	fs      fs.FS
@@ -58,6 +69,8 @@ func (c Context) clone() *Context {
 		parseFile:      c.parseFile,
 		schemaTypeName: c.schemaTypeName,
 		schemaPath:     c.schemaPath,
+		schemaFiles:    c.schemaFiles,
+		resolver:       c.resolver,
 	}
 }
 
@@ -67,6 +80,23 @@ func (c Context) WithSchema(path, typeName string) *Context {
 	return &c
 }
 
+// WithSchemaFiles is like WithSchema but looks up typeName in the given files
+// instead of a package import path.
+func (c Context) WithSchemaFiles(typeName string, files ...File) *Context {
+	c.schemaTypeName = typeName
+	c.schemaPath = ""
+	c.schemaFiles = files
+	return &c
+}
+
+// WithResolver rebuilds the value of the files with the files returned by
+// resolver until it has nothing more to add.
+func (c Context) WithResolver(resolver Resolver) *Context {
+	ret := c.clone()
+	ret.resolver = resolver
+	return ret
+}
+
 func (c Context) WithFile(name string, data []byte) *Context {
 	return c.WithFiles(File{
 		Filename: name,
@@ -128,7 +158,85 @@ func (c *Context) buildValue(args []string, files ...File) (*cue.Value, error) {
 	}
 
 	value := &values[0]
-	return value, WrapErr(value.Err())
+	return value, WrapErr(valueErr(*value))
+}
+
+// valueErr returns the errors of v. Errors raised while evaluating an
+// expression that is not a field, such as a function in a let, have no path.
+// These are reported at each field holding them instead.
+func valueErr(v cue.Value) error {
+	err := v.Err()
+	if err == nil || !hasPathless(err) {
+		return err
+	}
+	var result errors.Error
+	fieldErrs(v, &result)
+	if result == nil {
+		return err
+	}
+	return result
+}
+
+func hasPathless(err error) bool {
+	for _, e := range errors.Errors(err) {
+		if len(e.Path()) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldErrs adds the errors of the innermost fields of v that fail to result.
+func fieldErrs(v cue.Value, result *errors.Error) {
+	var (
+		children []cue.Value
+		found    bool
+	)
+	if it, err := v.Fields(cue.All()); err == nil {
+		for it.Next() {
+			children = append(children, it.Value())
+		}
+	} else if it, err := v.List(); err == nil {
+		for it.Next() {
+			children = append(children, it.Value())
+		}
+	}
+	for _, child := range children {
+		if child.Err() != nil {
+			found = true
+			fieldErrs(child, result)
+		}
+	}
+	if !found {
+		*result = errors.Append(*result, errors.Promote(v.Err(), ""))
+	}
+}
+
+func (c *Context) resolveValue(args []string, files []File) (*cue.Value, error) {
+	for i := 0; ; i++ {
+		value, err := c.buildValue(args, files...)
+		if c.resolver == nil || value == nil {
+			return value, err
+		}
+
+		data, resolveErr := c.resolver(*value)
+		if resolveErr != nil {
+			return nil, WrapErr(resolveErr)
+		}
+		if data == nil {
+			return value, err
+		}
+		if i == maxResolves {
+			return nil, fmt.Errorf("failed to resolve values after %d attempts", maxResolves)
+		}
+
+		filename := fmt.Sprintf("resolved_%d.cue", i)
+		files = append(files[:len(files):len(files)], File{
+			Filename: filename,
+			Data:     data,
+		})
+		args = append(args[:len(args):len(args)], filename)
+	}
 }
 
 func (c *Context) Validate(path, typeName string) error {
@@ -167,7 +275,7 @@ func (c *Context) ValueNoSchema() (*cue.Value, error) {
 		args = append(args, f.Filename)
 	}
 
-	return c.buildValue(args, c.files...)
+	return c.resolveValue(args, c.files)
 }
 
 func (c *Context) Value() (*cue.Value, error) {
@@ -176,7 +284,7 @@ func (c *Context) Value() (*cue.Value, error) {
 		args = append(args, f.Filename)
 	}
 
-	currentValue, err := c.buildValue(args, c.files...)
+	currentValue, err := c.resolveValue(args, c.files)
 	if err != nil {
 		return nil, err
 	}
@@ -184,11 +292,14 @@ func (c *Context) Value() (*cue.Value, error) {
 		return currentValue, nil
 	}
 
-	validation, err := c.buildValue([]string{c.schemaPath})
+	validation, err := c.schemaValue()
 	if err != nil {
 		return nil, err
 	}
 	schema := validation.LookupPath(cue.ParsePath(c.schemaTypeName))
+	if !schema.Exists() {
+		return nil, fmt.Errorf("failed to find schema type %s", c.schemaTypeName)
+	}
 
 	newValue := currentValue.Unify(schema)
 	if newValue.Err() != nil {
@@ -198,6 +309,18 @@ func (c *Context) Value() (*cue.Value, error) {
 	return &newValue, WrapErr(newValue.Validate())
 }
 
+func (c *Context) schemaValue() (*cue.Value, error) {
+	if len(c.schemaFiles) == 0 {
+		return c.buildValue([]string{c.schemaPath})
+	}
+
+	var args []string
+	for _, f := range c.schemaFiles {
+		args = append(args, f.Filename)
+	}
+	return c.buildValue(args, c.schemaFiles...)
+}
+
 func (c *Context) Decode(v *cue.Value, obj any) error {
 	data, err := v.MarshalJSON()
 	if err != nil {
@@ -3,12 +3,20 @@ package cue
 import (
 	"io/fs"
 	"path/filepath"
+	"strings"
 
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/load"
 	cueparser "cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
 )
 
+// IsInternal reports whether pos is in a file loaded from a nested FS, such as
+// the embedded schema, rather than in a file supplied by the user.
+func IsInternal(pos token.Pos) bool {
+	return strings.HasPrefix(pos.Filename(), dir)
+}
+
 func AddFS(target map[string]load.Source, cwd, prependPath string, files fs.FS) error {
 	return fs.WalkDir(files, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil || d.IsDir() {
@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	cuelang "cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
 	cue_mod "github.com/acorn-io/aml/cue.mod"
 	"github.com/acorn-io/aml/pkg/amlparser"
 	"github.com/acorn-io/aml/pkg/cue"
+	"github.com/acorn-io/aml/pkg/std"
 	"github.com/acorn-io/aml/schema"
 )
 
@@ -25,7 +29,86 @@ profiles: dev: dev: bool | *true
 `)
 
 type Definition struct {
-	ctx *cue.Context
+	ctx    *cue.Context
+	schema *CustomSchema
+	// spec and specErr hold the result of Args, which is computed once
+	specOnce sync.Once
+	spec     *ParamSpec
+	specErr  error
+}
+
+// CustomSchema replaces the Acornfile schema. The evaluated document,
+// excluding the args, profiles and std fields, must satisfy the definition
+// TypeName declared in Files.
+type CustomSchema struct {
+	TypeName string
+	Files    []cue.File
+}
+
+const schemaDocumentType = "#AMLDocument"
+
+// files returns the schema files along with a file declaring
+// schemaDocumentType, which extends TypeName to allow the AML specific fields.
+// All files are placed in the same package so they can reference each other.
+func (s *CustomSchema) files() (result []cue.File, _ error) {
+	if s.TypeName == "" {
+		return nil, fmt.Errorf("schema type name is required")
+	}
+	if !strings.HasPrefix(s.TypeName, "#") || !ast.IsValidIdent(s.TypeName) {
+		return nil, fmt.Errorf("schema type name %q is not a definition such as #Config", s.TypeName)
+	}
+	if len(s.Files) == 0 {
+		return nil, fmt.Errorf("schema has no files")
+	}
+
+	pkg := "schema"
+	for _, f := range s.Files {
+		file, err := parser.ParseFile(f.Filename, f.Data, parser.PackageClauseOnly)
+		if err != nil {
+			return nil, cue.WrapErr(err)
+		}
+		if name := file.PackageName(); name != "" {
+			pkg = name
+			break
+		}
+	}
+
+	for _, f := range s.Files {
+		f.Parser = withPackage(pkg, f.Parser)
+		result = append(result, f)
+	}
+
+	return append(result, cue.File{
+		Filename: "aml_document.cue",
+		Data: []byte(fmt.Sprintf(`package %s
+
+%s: {
+	%s
+	args?:     _
+	profiles?: _
+	std?:      _
+}
+`, pkg, schemaDocumentType, s.TypeName)),
+	}), nil
+}
+
+func withPackage(pkg string, parse cue.ParserFunc) cue.ParserFunc {
+	return func(name string, src any) (*ast.File, error) {
+		var (
+			file *ast.File
+			err  error
+		)
+		if parse == nil {
+			file, err = parser.ParseFile(name, src, parser.ParseComments)
+		} else {
+			file, err = parse(name, src)
+		}
+		if err != nil || file.PackageName() != "" {
+			return file, err
+		}
+		file.Decls = append([]ast.Decl{&ast.Package{Name: ast.NewIdent(pkg)}}, file.Decls...)
+		return file, nil
+	}
 }
 
 func NewAcornfile(data []byte) []cue.File {
@@ -40,7 +123,8 @@ func NewAcornfile(data []byte) []cue.File {
 func NewDefinition(files []cue.File) (*Definition, error) {
 	ctx := cue.NewContext().
 		WithNestedFS("schema", schema.Files).
-		WithNestedFS("cue.mod", cue_mod.Files)
+		WithNestedFS("cue.mod", cue_mod.Files).
+		WithResolver(std.Resolve)
 	ctx = ctx.WithFiles(files...)
 	ctx = ctx.WithSchema(Schema, AppType)
 	_, err := ctx.Value()
@@ -52,6 +136,29 @@ func NewDefinition(files []cue.File) (*Definition, error) {
 	}, nil
 }
 
+// NewDefinitionWithSchema is like NewDefinition but validates files against the
+// given schema instead of the Acornfile schema.
+func NewDefinitionWithSchema(files []cue.File, schema *CustomSchema) (*Definition, error) {
+	schemaFiles, err := schema.files()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := cue.NewContext().
+		WithNestedFS("cue.mod", cue_mod.Files).
+		WithResolver(std.Resolve)
+	ctx = ctx.WithFiles(files...)
+	ctx = ctx.WithSchemaFiles(schemaDocumentType, schemaFiles...)
+	_, err = ctx.Value()
+	if err != nil {
+		return nil, err
+	}
+	return &Definition{
+		ctx:    ctx,
+		schema: schema,
+	}, nil
+}
+
 func (a *Definition) getArgsForProfile(args map[string]any, profiles []string) (map[string]any, error) {
 	val, err := a.ctx.Value()
 	if err != nil {
@@ -92,11 +199,29 @@ func (a *Definition) getArgsForProfile(args map[string]any, profiles []string) (
 	return args, nil
 }
 
+// WithArgs returns the definition with the given args and the args set by the
+// profiles applied. Before the document is evaluated with the args they are
+// checked against the declared args, returning an *ArgsError listing all
+// required args that are missing and all args given that are not declared.
+// The check is skipped if the declared args can not be read, leaving the
+// errors to the evaluation.
 func (a *Definition) WithArgs(args map[string]any, profiles []string) (*Definition, map[string]any, error) {
+	spec, specErr := a.Args()
+
+	var given []string
+	for name := range args {
+		given = append(given, name)
+	}
+
 	args, err := a.getArgsForProfile(args, profiles)
 	if err != nil {
 		return nil, nil, err
 	}
+	if specErr == nil {
+		if err := checkArgs(spec, given, args); err != nil {
+			return nil, nil, err
+		}
+	}
 	if len(args) == 0 {
 		return a, args, nil
 	}
@@ -107,7 +232,8 @@ func (a *Definition) WithArgs(args map[string]any, profiles []string) (*Definiti
 		return nil, nil, err
 	}
 	return &Definition{
-		ctx: a.ctx.WithFile("args.cue", data),
+		ctx:    a.ctx.WithFile("args.cue", data),
+		schema: a.schema,
 	}, args, nil
 }
 
@@ -117,6 +243,10 @@ func (a *Definition) Decode(spec interface{}) error {
 		return err
 	}
 
+	if a.schema != nil {
+		return a.decodeDocument(app, spec)
+	}
+
 	objs := map[string]any{}
 	for _, key := range []string{"containers", "jobs", "acorns", "secrets", "volumes", "images", "routers", "labels", "annotations", "services"} {
 		v := app.LookupPath(cuelang.ParsePath(key))
@@ -132,3 +262,28 @@ func (a *Definition) Decode(spec interface{}) error {
 
 	return a.ctx.Decode(newApp, spec)
 }
+
+// decodeDocument decodes all fields of the document except for the args,
+// profiles and std fields.
+func (a *Definition) decodeDocument(app *cuelang.Value, spec interface{}) error {
+	iter, err := app.Fields()
+	if err != nil {
+		return cue.WrapErr(err)
+	}
+
+	objs := map[string]any{}
+	for iter.Next() {
+		switch key := iter.Label(); key {
+		case "args", "profiles", "std":
+		default:
+			objs[key] = iter.Value()
+		}
+	}
+
+	newApp, err := a.ctx.Encode(objs)
+	if err != nil {
+		return err
+	}
+
+	return a.ctx.Decode(newApp, spec)
+}
@@ -1,10 +1,36 @@
 package definition
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/acorn-io/aml/pkg/amlparser"
+	"github.com/acorn-io/aml/pkg/std"
+	"github.com/stretchr/testify/assert"
 )
 
+func init() {
+	err := std.Register("testRegistry", []std.Signature{{{"string"}, {"string"}}}, func(args []any) (any, error) {
+		if args[1] == "" {
+			return nil, fmt.Errorf("image name is empty")
+		}
+		return fmt.Sprintf("registry.example.com/%s/%s", args[0], args[1]), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	err = std.Register("testUpper", nil, func(args []any) (any, error) {
+		return strings.ToUpper(fmt.Sprint(args...)), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
 func TestStd(t *testing.T) {
 	data, err := os.ReadFile("../std/std_test.cue")
 	if err != nil {
@@ -22,3 +48,197 @@ func TestStd(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestElse(t *testing.T) {
+	data := []byte(`
+args: size: "medium"
+
+containers: web: {
+	image: "nginx"
+	if args.size == "small" {
+		scale: 1
+	} else if args.size == "medium" {
+		scale: 2
+	} else {
+		scale: 3
+	}
+	ports: [80, if args.dev {8080} else {443}]
+}
+`)
+
+	def, err := NewDefinition(NewAcornfile(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for size, scale := range map[string]float64{"small": 1, "medium": 2, "large": 3} {
+		def, _, err := def.WithArgs(map[string]any{"size": size}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result := map[string]any{}
+		if err := def.Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+
+		web := result["containers"].(map[string]any)["web"].(map[string]any)
+		assert.Equal(t, scale, web["scale"])
+		assert.Equal(t, []any{float64(80), float64(443)}, web["ports"])
+	}
+}
+
+func TestNative(t *testing.T) {
+	data := []byte(`
+args: team: "web"
+
+containers: {
+	web: image: std.testRegistry(args.team, "nginx")
+	upper: image: std.testUpper(std.testRegistry(args.team, "app"))
+	interp: image: "\(std.testUpper(args.team)):latest"
+	for i, name in ["a", "b"] {
+		"list\(i)": image: std.testRegistry(name, "app")
+	}
+	if std.testUpper(args.team) == "OPS" {
+		ops: image: "ops"
+	}
+}
+`)
+
+	def, err := NewDefinition(NewAcornfile(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	def, _, err = def.WithArgs(map[string]any{"team": "ops"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := map[string]any{}
+	if err := def.Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	images := map[string]any{}
+	for name, container := range result["containers"].(map[string]any) {
+		images[name] = container.(map[string]any)["image"]
+	}
+	assert.Equal(t, map[string]any{
+		"web":    "registry.example.com/ops/nginx",
+		"upper":  "REGISTRY.EXAMPLE.COM/OPS/APP",
+		"interp": "OPS:latest",
+		"list0":  "registry.example.com/a/app",
+		"list1":  "registry.example.com/b/app",
+		"ops":    "ops",
+	}, images)
+}
+
+func TestNativeErrors(t *testing.T) {
+	_, err := NewDefinition(NewAcornfile([]byte(`containers: web: image: std.testRegistry("web")`)))
+	assert.ErrorContains(t, err, "std.testRegistry: expected 2 arguments, got 1")
+
+	_, err = NewDefinition(NewAcornfile([]byte(`containers: web: image: std.testRegistry("web", "")`)))
+	assert.ErrorContains(t, err, "error in call to std.testRegistry: image name is empty")
+
+	assert.ErrorContains(t, std.Register("split", nil, func([]any) (any, error) { return nil, nil }), "std.split is already defined")
+	assert.ErrorContains(t, std.Register("testLate", nil, func([]any) (any, error) { return nil, nil }), "std.testLate: functions must be registered before AML is evaluated")
+}
+
+func TestStdOnlyUsed(t *testing.T) {
+	file, err := amlparser.ParseFile("Acornfile", `
+a: std.toUpper("x")
+b: std.merge({a: 1}, {b: 2})
+c: std.testRegistry("web", "nginx")
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		imports   []string
+		functions []string
+	)
+	for _, spec := range file.Imports {
+		imports = append(imports, spec.Name.Name)
+	}
+	for _, decl := range file.Decls {
+		if let, ok := decl.(*ast.LetClause); ok {
+			for _, e := range let.Expr.(*ast.StructLit).Elts {
+				functions = append(functions, e.(*ast.Field).Label.(*ast.Ident).Name)
+			}
+		}
+	}
+	assert.Equal(t, []string{"_std_strings", "_std_json"}, imports)
+	assert.Equal(t, []string{"toUpper", "merge", "testRegistry"}, functions)
+
+	file, err = amlparser.ParseFile("Acornfile", `a: "std"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, file.Imports)
+	assert.Len(t, file.Decls, 1)
+
+	def, err := NewDefinition(NewAcornfile([]byte(`containers: web: image: std.toUpper("nginx")`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := map[string]any{}
+	if err := def.Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "NGINX", result["containers"].(map[string]any)["web"].(map[string]any)["image"])
+}
+
+// BenchmarkStd compares evaluating a file with only the std functions it calls
+// injected, as done by amlparser.ParseFile, against one with all of them.
+func BenchmarkStd(b *testing.B) {
+	src := &strings.Builder{}
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(src, "containers: c%d: image: std.toUpper(\"nginx%d\")\n", i, i)
+	}
+
+	selected, err := amlparser.ParseFile("Acornfile", src.String())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Replace the selected functions by the whole library, pointing the
+	// references to std at the library's declaration
+	parsed, err := amlparser.ParseFile("Acornfile", src.String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	all := &ast.File{
+		Filename: parsed.Filename,
+		Imports:  std.Library.Imports,
+		Decls:    append([]ast.Decl{}, std.Library.Decls...),
+	}
+	for _, decl := range parsed.Decls {
+		if decl.Pos().Filename() == std.Filename {
+			continue
+		}
+		ast.Walk(decl, func(n ast.Node) bool {
+			if i, ok := n.(*ast.Ident); ok && i.Name == "std" {
+				i.Node = std.Library.Decls[1]
+			}
+			return true
+		}, nil)
+		all.Decls = append(all.Decls, decl)
+	}
+
+	for _, bench := range []struct {
+		name string
+		file *ast.File
+	}{
+		{name: "selected", file: selected},
+		{name: "all", file: all},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := cuecontext.New().BuildFile(bench.file).Validate(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package definition
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect returned by ArgsJSONSchema.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// ArgsJSONSchema returns a JSON Schema describing the args, which validates
+// the args of a deploy request. Descriptions come from the comments of the
+// args and defaults, enums, bounds, patterns, element types and nested
+// objects from their schemas. Args that may be of more than one type are
+// given the list of their types. Args not declared are rejected.
+//
+// The profiles are listed as presets in the x-profiles keyword, mapping each
+// name to its description and the args it sets. Args of a profile that depend
+// on other args are left out.
+func (a *Definition) ArgsJSONSchema() ([]byte, error) {
+	spec, err := a.Args()
+	if err != nil {
+		return nil, err
+	}
+
+	schema := objectJSONSchema(spec.Params)
+	schema["$schema"] = JSONSchemaDraft
+	schema["additionalProperties"] = false
+
+	if len(spec.Profiles) > 0 {
+		app, err := a.ctx.ValueNoSchema()
+		if err != nil {
+			return nil, err
+		}
+		profiles := map[string]any{}
+		for _, profile := range spec.Profiles {
+			preset := map[string]any{}
+			if profile.Description != "" {
+				preset["description"] = profile.Description
+			}
+			if args := concreteFields(app.LookupPath(cue.MakePath(cue.Str("profiles"), cue.Str(profile.Name)))); len(args) > 0 {
+				preset["args"] = args
+			}
+			profiles[profile.Name] = preset
+		}
+		schema["x-profiles"] = profiles
+	}
+
+	return json.Marshal(schema)
+}
+
+func objectJSONSchema(params []Param) map[string]any {
+	var (
+		properties = map[string]any{}
+		required   []string
+	)
+	for _, param := range params {
+		properties[param.Name] = paramJSONSchema(param)
+		if param.Required {
+			required = append(required, param.Name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonSchemaTypes maps the names of Param.Types to JSON Schema types.
+var jsonSchemaTypes = map[string]string{
+	"string": "string",
+	"int":    "integer",
+	"float":  "number",
+	"bool":   "boolean",
+	"array":  "array",
+	"object": "object",
+	"null":   "null",
+}
+
+func paramJSONSchema(param Param) map[string]any {
+	schema := map[string]any{}
+	switch param.Type {
+	case "":
+		if len(param.Types) > 0 {
+			var types []string
+			for _, t := range param.Types {
+				types = append(types, jsonSchemaTypes[t])
+			}
+			schema["type"] = types
+		}
+	case "string":
+		schema["type"] = "string"
+	case "enum":
+		schema["type"] = "string"
+		schema["enum"] = param.Options
+	case "int":
+		schema["type"] = "integer"
+	case "float":
+		schema["type"] = "number"
+	case "bool":
+		schema["type"] = "boolean"
+	case "array":
+		schema["type"] = "array"
+		if param.Items != nil {
+			schema["items"] = paramJSONSchema(*param.Items)
+		}
+	case "object":
+		if len(param.Params) > 0 {
+			schema = objectJSONSchema(param.Params)
+		} else {
+			schema["type"] = "object"
+		}
+	}
+
+	if param.Description != "" {
+		schema["description"] = param.Description
+	}
+	if param.Default != nil {
+		schema["default"] = param.Default
+	}
+	if param.Min != nil {
+		if param.ExclusiveMin {
+			schema["exclusiveMinimum"] = *param.Min
+		} else {
+			schema["minimum"] = *param.Min
+		}
+	}
+	if param.Max != nil {
+		if param.ExclusiveMax {
+			schema["exclusiveMaximum"] = *param.Max
+		} else {
+			schema["maximum"] = *param.Max
+		}
+	}
+	var patterns []map[string]any
+	for _, pattern := range param.Patterns {
+		patterns = append(patterns, map[string]any{"pattern": pattern})
+	}
+	for _, pattern := range param.NotPatterns {
+		patterns = append(patterns, map[string]any{"not": map[string]any{"pattern": pattern}})
+	}
+	switch len(patterns) {
+	case 0:
+	case 1:
+		for k, v := range patterns[0] {
+			schema[k] = v
+		}
+	default:
+		schema["allOf"] = patterns
+	}
+	return schema
+}
+
+// concreteFields decodes the fields of the struct v that are concrete and do
+// not reference other values.
+func concreteFields(v cue.Value) map[string]any {
+	result := map[string]any{}
+	iter, err := v.Fields()
+	if err != nil {
+		return result
+	}
+	for iter.Next() {
+		var value any
+		if hasReference(iter.Value().Source()) || iter.Value().Validate(cue.Concrete(true)) != nil {
+			continue
+		}
+		if err := iter.Value().Decode(&value); err != nil {
+			continue
+		}
+		result[iter.Label()] = value
+	}
+	return result
+}
+
+var predeclaredType = regexp.MustCompile(`^(u?int(8|16|32|64|128)?|float(32|64)?|number|string|bytes|rune|bool|_)$`)
+
+// hasReference reports whether the value of node refers to anything other
+// than the predeclared types.
+func hasReference(node ast.Node) bool {
+	if field, ok := node.(*ast.Field); ok {
+		node = field.Value
+	}
+	found := false
+	if node != nil {
+		ast.Walk(node, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && !predeclaredType.MatchString(ident.Name) {
+				found = true
+			}
+			return !found
+		}, nil)
+	}
+	return found
+}
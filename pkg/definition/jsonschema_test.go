@@ -0,0 +1,76 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgsJSONSchema(t *testing.T) {
+	acornCue := `
+args: {
+	// Number of replicas
+	replicas: int & >=1 & <=10 | *1
+	// Hostname to serve
+	host: =~"^[a-z.]+$" & !~"^tmp"
+	mode: "dev" | "prod"
+	"log-level": "info" | "debug"
+	ratio: <1
+	dir: !~"^/tmp"
+	value: int | string
+	tags: ["a"]
+	names: [...string]
+	config: {
+		port: int
+		path: "/"
+	}
+}
+
+profiles: {
+	// Production settings
+	prod: {
+		replicas: 3
+		mode: "prod"
+		host: "x." + args.mode
+	}
+}
+`
+	def, err := NewDefinition(NewAcornfile([]byte(acornCue)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := def.ArgsJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["host", "ratio", "dir", "value", "config"],
+		"properties": {
+			"replicas": {"type": "integer", "description": "Number of replicas", "default": 1, "minimum": 1, "maximum": 10},
+			"host": {"type": "string", "description": "Hostname to serve", "allOf": [{"pattern": "^[a-z.]+$"}, {"not": {"pattern": "^tmp"}}]},
+			"mode": {"type": "string", "enum": ["dev", "prod"], "default": "dev"},
+			"log-level": {"type": "string", "enum": ["info", "debug"], "default": "info"},
+			"ratio": {"type": "number", "exclusiveMaximum": 1},
+			"dir": {"type": "string", "not": {"pattern": "^/tmp"}},
+			"value": {"type": ["integer", "string"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "default": ["a"]},
+			"names": {"type": "array", "items": {"type": "string"}},
+			"config": {
+				"type": "object",
+				"required": ["port"],
+				"properties": {
+					"port": {"type": "integer"},
+					"path": {"type": "string", "default": "/"}
+				}
+			}
+		},
+		"x-profiles": {
+			"prod": {"description": "Production settings", "args": {"replicas": 3, "mode": "prod"}}
+		}
+	}`, string(schema))
+}
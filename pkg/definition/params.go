@@ -2,10 +2,14 @@ package definition
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
 	"github.com/acorn-io/aml/pkg/amlparser"
 )
 
@@ -17,8 +21,31 @@ type ParamSpec struct {
 type Param struct {
 	Name        string `json:"name,omitempty"`
 	Description string `json:"description,omitempty"`
-	Type        string `json:"type,omitempty" wrangler:"options=string|int|float|bool|object|array"`
-	Schema      string `json:"schema,omitempty"`
+	Type        string `json:"type,omitempty" wrangler:"options=string|int|float|bool|enum|object|array"`
+	// Types are the types an arg of more than one type, which has no Type,
+	// may have. They are named as Type is, and null for the null value.
+	Types  []string `json:"types,omitempty"`
+	Schema string   `json:"schema,omitempty"`
+	// Default is the value used if the arg is not given, decoded as JSON
+	Default any `json:"default,omitempty"`
+	// Options are the allowed values of an enum
+	Options []string `json:"options,omitempty"`
+	// Required is set if the arg has no default and must be given
+	Required bool `json:"required,omitempty"`
+	// Min and Max are the bounds of a number, exclusive if ExclusiveMin or
+	// ExclusiveMax is set
+	Min          *float64 `json:"min,omitempty"`
+	Max          *float64 `json:"max,omitempty"`
+	ExclusiveMin bool     `json:"exclusiveMin,omitempty"`
+	ExclusiveMax bool     `json:"exclusiveMax,omitempty"`
+	// Patterns are the regular expressions a string must match and
+	// NotPatterns the ones it must not match
+	Patterns    []string `json:"patterns,omitempty"`
+	NotPatterns []string `json:"notPatterns,omitempty"`
+	// Items describes the elements of an array
+	Items *Param `json:"items,omitempty"`
+	// Params are the fields of an object
+	Params []Param `json:"params,omitempty"`
 }
 
 type Profile struct {
@@ -26,8 +53,81 @@ type Profile struct {
 	Description string `json:"description,omitempty"`
 }
 
+// Args returns the declared args and profiles. The spec is computed once and
+// each call returns a copy of it.
 func (a *Definition) Args() (*ParamSpec, error) {
-	return a.addProfiles(a.args("args"))
+	a.specOnce.Do(func() {
+		a.spec, a.specErr = a.addProfiles(a.args("args"))
+	})
+	if a.specErr != nil {
+		return nil, a.specErr
+	}
+	return a.spec.DeepCopy(), nil
+}
+
+// DeepCopy returns a copy of the spec sharing no memory with it.
+func (p *ParamSpec) DeepCopy() *ParamSpec {
+	if p == nil {
+		return nil
+	}
+	return &ParamSpec{
+		Params:   copyParams(p.Params),
+		Profiles: append([]Profile(nil), p.Profiles...),
+	}
+}
+
+func copyParams(params []Param) []Param {
+	if params == nil {
+		return nil
+	}
+	result := make([]Param, 0, len(params))
+	for _, param := range params {
+		result = append(result, param.DeepCopy())
+	}
+	return result
+}
+
+// DeepCopy returns a copy of the param sharing no memory with it.
+func (p Param) DeepCopy() Param {
+	result := p
+	result.Default = copyValue(p.Default)
+	result.Types = append([]string(nil), p.Types...)
+	result.Options = append([]string(nil), p.Options...)
+	result.Patterns = append([]string(nil), p.Patterns...)
+	result.NotPatterns = append([]string(nil), p.NotPatterns...)
+	result.Params = copyParams(p.Params)
+	if p.Min != nil {
+		min := *p.Min
+		result.Min = &min
+	}
+	if p.Max != nil {
+		max := *p.Max
+		result.Max = &max
+	}
+	if p.Items != nil {
+		items := p.Items.DeepCopy()
+		result.Items = &items
+	}
+	return result
+}
+
+// copyValue copies a value decoded from JSON.
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case []any:
+		result := make([]any, 0, len(v))
+		for _, item := range v {
+			result = append(result, copyValue(item))
+		}
+		return result
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, item := range v {
+			result[k] = copyValue(item)
+		}
+		return result
+	}
+	return value
 }
 
 func (a *Definition) addProfiles(paramSpec *ParamSpec, err error) (*ParamSpec, error) {
@@ -57,6 +157,22 @@ func (a *Definition) args(section string) (*ParamSpec, error) {
 	}
 
 	v := app.LookupPath(cue.ParsePath(section))
+	if !v.Exists() {
+		return &ParamSpec{}, nil
+	}
+
+	params, err := newParams(v, true)
+	if err != nil {
+		return nil, err
+	}
+	return &ParamSpec{
+		Params: params,
+	}, nil
+}
+
+// newParams returns the params for the fields of the struct v. The dev field
+// is skipped at the top level.
+func newParams(v cue.Value, topLevel bool) ([]Param, error) {
 	sv, err := v.Struct()
 	if err != nil {
 		return nil, err
@@ -65,8 +181,11 @@ func (a *Definition) args(section string) (*ParamSpec, error) {
 	// I have no clue what I'm doing here, just poked around
 	// until something worked
 
-	result := &ParamSpec{}
-	node := v.Syntax(cue.Docs(true))
+	var result []Param
+	node, err := syntax(v, cue.Docs(true))
+	if err != nil {
+		return nil, err
+	}
 	s, ok := node.(*ast.StructLit)
 	if !ok {
 		return result, nil
@@ -74,7 +193,11 @@ func (a *Definition) args(section string) (*ParamSpec, error) {
 
 	for i, o := range s.Elts {
 		f := o.(*ast.Field)
-		if fmt.Sprint(f.Label) == "dev" {
+		name, _, err := ast.LabelName(f.Label)
+		if err != nil {
+			return nil, err
+		}
+		if topLevel && name == "dev" {
 			continue
 		}
 		com := strings.Builder{}
@@ -87,35 +210,302 @@ func (a *Definition) args(section string) (*ParamSpec, error) {
 				com.WriteString("\n")
 			}
 		}
-		result.Params = append(result.Params, Param{
-			Name:        fmt.Sprint(f.Label),
-			Description: strings.TrimSpace(com.String()),
-			Schema:      fmt.Sprint(sv.Field(i).Value),
-			Type:        getType(sv.Field(i).Value, f.Value),
-		})
+		param, err := newParam(sv.Field(i).Value, f.Value)
+		if err != nil {
+			return nil, err
+		}
+		param.Name = name
+		param.Description = strings.TrimSpace(com.String())
+		result = append(result, param)
 	}
 
 	return result, nil
 }
 
+func newParam(v cue.Value, expr ast.Expr) (Param, error) {
+	param := Param{
+		Schema: fmt.Sprint(v),
+		Type:   getType(v, expr),
+	}
+	if param.Type == "" {
+		param.Types = getTypes(v)
+	}
+
+	def, hasDefault := v.Default()
+	switch {
+	case hasDefault && markedDefault(v):
+		if err := def.Decode(&param.Default); err != nil {
+			return param, err
+		}
+		if list, ok := param.Default.([]any); ok && list == nil {
+			param.Default = []any{}
+		}
+	case def.Validate(cue.Concrete(true)) == nil:
+		// Open lists and empty structs are concrete, but an empty value is
+		// not worth reporting as the default
+		var value any
+		if err := def.Decode(&value); err != nil {
+			return param, err
+		}
+		if !isEmpty(value) {
+			param.Default = value
+		}
+	default:
+		param.Required = true
+	}
+
+	addConstraints(&param, expr)
+
+	if param.Type == "object" && v.IncompleteKind() == cue.StructKind {
+		params, err := newParams(v, false)
+		if err != nil {
+			return param, err
+		}
+		param.Params = params
+	}
+
+	if elem := v.LookupPath(cue.MakePath(cue.AnyIndex)); param.Type == "array" && elem.Exists() {
+		if node, err := syntax(elem); err != nil {
+			return param, err
+		} else if elemExpr, ok := node.(ast.Expr); ok {
+			items, err := newParam(elem, elemExpr)
+			if err != nil {
+				return param, err
+			}
+			// Elements are never left out, so there is nothing to require
+			items.Required = false
+			param.Items = &items
+		}
+	}
+	return param, nil
+}
+
+// syntax returns the syntax of v. Exporting values that refer to the std
+// functions can panic in CUE, which is returned as an error instead.
+func syntax(v cue.Value, opts ...cue.Option) (node ast.Node, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to read the schema of %s: %v", v.Path(), r)
+		}
+	}()
+	return v.Syntax(opts...), nil
+}
+
+// markedDefault reports whether the default of v is marked with *. Open
+// lists report a default even if none is marked.
+func markedDefault(v cue.Value) bool {
+	node, err := syntax(v)
+	if err != nil {
+		return false
+	}
+	found := false
+	ast.Walk(node, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.UnaryExpr:
+			found = found || n.Op == token.MUL
+		case *ast.BinaryExpr:
+			return n.Op == token.OR
+		case *ast.ParenExpr:
+			return true
+		}
+		return false
+	}, nil)
+	return found
+}
+
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+// addConstraints sets the enum options, bounds and patterns of param from the
+// constraints in expr.
+func addConstraints(param *Param, expr ast.Expr) {
+	ast.Walk(expr, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.StructLit, *ast.ListLit:
+			return false
+		case *ast.BasicLit:
+			if param.Type == "enum" && n.Kind == token.STRING {
+				if s, err := literal.Unquote(n.Value); err == nil {
+					param.Options = append(param.Options, s)
+				}
+			}
+		case *ast.UnaryExpr:
+			switch n.Op {
+			case token.GEQ, token.GTR:
+				if f, ok := number(n.X); ok {
+					param.Min, param.ExclusiveMin = &f, n.Op == token.GTR
+				}
+				return false
+			case token.LEQ, token.LSS:
+				if f, ok := number(n.X); ok {
+					param.Max, param.ExclusiveMax = &f, n.Op == token.LSS
+				}
+				return false
+			case token.MAT, token.NMAT:
+				if lit, ok := n.X.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if s, err := literal.Unquote(lit.Value); err == nil && n.Op == token.MAT {
+						param.Patterns = append(param.Patterns, s)
+					} else if err == nil {
+						param.NotPatterns = append(param.NotPatterns, s)
+					}
+				}
+				return false
+			case token.NEQ:
+				return false
+			}
+		}
+		return true
+	}, nil)
+}
+
+func number(expr ast.Expr) (float64, bool) {
+	if u, ok := expr.(*ast.UnaryExpr); ok && (u.Op == token.SUB || u.Op == token.ADD) {
+		f, ok := number(u.X)
+		if u.Op == token.SUB {
+			f = -f
+		}
+		return f, ok
+	}
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || (lit.Kind != token.INT && lit.Kind != token.FLOAT) {
+		return 0, false
+	}
+	info := literal.NumInfo{}
+	if err := literal.ParseNum(lit.Value, &info); err != nil {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(info.String(), 64)
+	return f, err == nil
+}
+
 func getType(v cue.Value, expr ast.Expr) string {
-	if _, err := v.String(); err == nil {
-		if amlparser.AllLitStrings(expr, true) {
+	if def, ok := v.Default(); ok {
+		v = def
+	}
+	switch v.IncompleteKind() {
+	case cue.StringKind:
+		// A single string literal is a constant rather than an enum
+		if _, lit := expr.(*ast.BasicLit); !lit && amlparser.AllLitStrings(expr, true) {
 			return "enum"
 		}
 		return "string"
-	}
-	if _, err := v.Bool(); err == nil {
+	case cue.BoolKind:
 		return "bool"
-	}
-	if _, err := v.Int(nil); err == nil {
+	case cue.IntKind:
 		return "int"
-	}
-	if _, err := v.Float64(); err == nil {
+	case cue.FloatKind, cue.NumberKind:
 		return "float"
-	}
-	if _, err := v.List(); err == nil {
+	case cue.ListKind:
 		return "array"
+	case cue.StructKind:
+		return "object"
+	}
+	// The value may be of more than one type
+	return ""
+}
+
+// getTypes returns the types a value of more than one type may have, or nil
+// if it may be of any type.
+func getTypes(v cue.Value) (result []string) {
+	kind := v.IncompleteKind()
+	if kind == cue.TopKind {
+		return nil
+	}
+	if kind&cue.NumberKind == cue.IntKind {
+		result = append(result, "int")
+	} else if kind&cue.NumberKind != 0 {
+		result = append(result, "float")
+	}
+	for _, t := range []struct {
+		kind cue.Kind
+		name string
+	}{
+		{cue.StringKind, "string"},
+		{cue.BoolKind, "bool"},
+		{cue.ListKind, "array"},
+		{cue.StructKind, "object"},
+		{cue.NullKind, "null"},
+	} {
+		if kind&t.kind != 0 {
+			result = append(result, t.name)
+		}
+	}
+	return result
+}
+
+// ArgsError is returned by WithArgs if required args are missing or args that
+// are not declared are given.
+type ArgsError struct {
+	Errors []ArgError
+}
+
+func (e *ArgsError) Error() string {
+	var lines []string
+	for _, err := range e.Errors {
+		lines = append(lines, "args."+err.Name+": "+err.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ArgError describes a single missing or unknown arg. Suggestions are the
+// declared args closest to the name of an unknown arg.
+type ArgError struct {
+	Name        string
+	Message     string
+	Suggestions []string
+}
+
+func (e ArgError) Error() string {
+	return e.Message
+}
+
+// checkArgs returns an *ArgsError listing the required params missing from
+// args and the given names that are not params.
+func checkArgs(spec *ParamSpec, given []string, args map[string]any) error {
+	var (
+		result   = &ArgsError{}
+		declared = map[string]bool{}
+	)
+	for _, param := range spec.Params {
+		declared[param.Name] = true
+		if _, ok := args[param.Name]; param.Required && !ok {
+			result.Errors = append(result.Errors, ArgError{
+				Name:    param.Name,
+				Message: fmt.Sprintf("missing required %s arg", param.Type),
+			})
+		}
+	}
+
+	sort.Strings(given)
+	for _, name := range given {
+		// dev is always allowed and not listed in the params
+		if declared[name] || name == "dev" {
+			continue
+		}
+		argErr := ArgError{
+			Name:        name,
+			Message:     "unknown arg",
+			Suggestions: amlparser.ClosestMatches(name, declared, len(name)/2),
+		}
+		if len(argErr.Suggestions) > 0 {
+			var quoted []string
+			for _, suggestion := range argErr.Suggestions {
+				quoted = append(quoted, strconv.Quote(suggestion))
+			}
+			argErr.Message += ", did you mean " + strings.Join(quoted, " or ") + "?"
+		}
+		result.Errors = append(result.Errors, argErr)
+	}
+
+	if len(result.Errors) > 0 {
+		return result
 	}
-	return "object"
+	return nil
 }
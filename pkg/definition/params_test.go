@@ -120,3 +120,157 @@ containers: {
 	assert.Equal(t, 3, args["replicas"])
 	assert.Equal(t, float64(3), result["containers"].(map[string]any)["web"].(map[string]any)["scale"])
 }
+
+func TestParamConstraints(t *testing.T) {
+	acornCue := `
+args: {
+	name: string
+	replicas: int & >=1 & <=10 | *3
+	ratio: >-0.5 & <1
+	host: =~"^[a-z.]+$" & !~"^tmp"
+	mode: "dev" | "prod"
+	value: int | string
+	tags: ["a"]
+	names: [...string]
+	ports: [...int] | *[]
+	config: {
+		// Port to listen on
+		port: int
+		path: "/"
+	}
+}
+`
+	def, err := NewDefinition(NewAcornfile([]byte(acornCue)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := def.Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	one, ten, minusHalf := 1.0, 10.0, -0.5
+	assert.Equal(t, []Param{
+		{Name: "name", Type: "string", Schema: "string", Required: true},
+		{Name: "replicas", Type: "int", Schema: "*3 | uint & >=1 & <=10", Default: 3, Min: &one, Max: &ten},
+		{Name: "ratio", Type: "float", Schema: ">-0.5 & <1", Required: true, Min: &minusHalf, Max: &one, ExclusiveMin: true, ExclusiveMax: true},
+		{Name: "host", Type: "string", Schema: `=~"^[a-z.]+$" & !~"^tmp"`, Required: true, Patterns: []string{"^[a-z.]+$"}, NotPatterns: []string{"^tmp"}},
+		{Name: "mode", Type: "enum", Schema: `*"dev" | "prod"`, Default: "dev", Options: []string{"dev", "prod"}},
+		{Name: "value", Types: []string{"int", "string"}, Schema: "int | string", Required: true},
+		{Name: "tags", Type: "array", Schema: `*["a"] | [...string]`, Default: []any{"a"}, Items: &Param{Type: "string", Schema: "string"}},
+		{Name: "names", Type: "array", Schema: "[...string]", Items: &Param{Type: "string", Schema: "string"}},
+		{Name: "ports", Type: "array", Schema: "*[] | [...int]", Default: []any{}},
+		{Name: "config", Type: "object", Schema: "{\n\tport: int\n\tpath: \"/\"\n}", Required: true, Params: []Param{
+			{Name: "port", Description: "Port to listen on", Type: "int", Schema: "int", Required: true},
+			{Name: "path", Type: "string", Schema: `"/"`, Default: "/"},
+		}},
+	}, spec.Params)
+}
+
+func TestWithArgsCheck(t *testing.T) {
+	acornCue := `
+args: {
+	image: string
+	port: int
+	replicas: 1
+}
+
+profiles: prod: {
+	port: 443
+}
+
+containers: web: {
+	image: args.image
+	scale: args.replicas
+	ports: args.port
+}
+`
+	def, err := NewDefinition(NewAcornfile([]byte(acornCue)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = def.WithArgs(map[string]any{"replcas": 2, "foo": 1}, nil)
+	assert.Equal(t, &ArgsError{
+		Errors: []ArgError{
+			{Name: "image", Message: "missing required string arg"},
+			{Name: "port", Message: "missing required int arg"},
+			{Name: "foo", Message: "unknown arg"},
+			{Name: "replcas", Message: `unknown arg, did you mean "replicas"?`, Suggestions: []string{"replicas"}},
+		},
+	}, err)
+
+	_, args, err := def.WithArgs(map[string]any{"image": "nginx", "dev": true}, []string{"prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "nginx", args["image"])
+	assert.Equal(t, 443, args["port"])
+}
+
+func TestWithArgsQuotedName(t *testing.T) {
+	acornCue := `
+args: {
+	"foo-bar": string | *"a"
+}
+
+containers: web: image: args["foo-bar"]
+`
+	def, err := NewDefinition(NewAcornfile([]byte(acornCue)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := def.Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "foo-bar", spec.Params[0].Name)
+
+	_, args, err := def.WithArgs(map[string]any{"foo-bar": "b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "b", args["foo-bar"])
+}
+
+func TestArgsCopy(t *testing.T) {
+	def, err := NewDefinition(NewAcornfile([]byte(`args: tags: ["a"]`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := def.Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Params[0].Name = "changed"
+	spec.Params[0].Default.([]any)[0] = "b"
+
+	again, err := def.Args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "tags", again.Params[0].Name)
+	assert.Equal(t, []any{"a"}, again.Params[0].Default)
+}
+
+func TestArgsStdCall(t *testing.T) {
+	def, err := NewDefinition(NewAcornfile([]byte(`
+args: n: std.toUpper("x")
+containers: web: image: args.n
+`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = def.Args()
+	assert.Error(t, err)
+
+	def, _, err = def.WithArgs(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, def.Decode(&map[string]any{}))
+}
@@ -9,12 +9,14 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/acorn-io/aml/pkg/amlparser"
 	"github.com/acorn-io/aml/pkg/cue"
 	"github.com/acorn-io/aml/pkg/definition"
+	"github.com/acorn-io/aml/pkg/std"
 )
 
 func CreateReader(path string) (io.ReadCloser, error) {
@@ -86,26 +88,25 @@ func ToFiles(r io.Reader) (result []cue.File, _ error) {
 		return nil, err
 	}
 
-	files := map[string]interface{}{}
+	files := map[string]string{}
 	for {
-		if header.Typeflag != tar.TypeReg {
-			continue
-		}
-		content, err := io.ReadAll(tarReader)
-		if err != nil {
-			return nil, err
-		}
-		if strings.HasSuffix(strings.ToLower(header.Name), ".aml") {
-			result = append(result, cue.File{
-				Filename:    header.Name[:len(header.Name)-3] + ".cue",
-				DisplayName: header.Name,
-				Data:        data,
-				Parser:      amlparser.ParseFile,
-			})
-		} else if !utf8.Valid(content) {
-			return nil, fmt.Errorf("Invalid utf-8 content in [%s]", header.Name)
-		} else {
-			addFile(files, header.Name, string(content))
+		if header.Typeflag == tar.TypeReg {
+			content, err := io.ReadAll(tarReader)
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasSuffix(strings.ToLower(header.Name), ".aml") {
+				result = append(result, cue.File{
+					Filename:    header.Name[:len(header.Name)-3] + ".cue",
+					DisplayName: header.Name,
+					Data:        content,
+					Parser:      amlparser.ParseFile,
+				})
+			} else if !utf8.Valid(content) {
+				return nil, fmt.Errorf("Invalid utf-8 content in [%s]", header.Name)
+			} else {
+				files[path.Clean(header.Name)] = string(content)
+			}
 		}
 
 		header, err = tarReader.Next()
@@ -127,34 +128,54 @@ func ToFiles(r io.Reader) (result []cue.File, _ error) {
 	return result, nil
 }
 
-func toFiles(files map[string]any) (cue.File, error) {
-	data, err := json.Marshal(map[string]any{
-		"std": map[string]any{
-			"files": files,
-		},
+// FSToFiles returns all .aml and .cue files found in fsys. Files ending in
+// .aml are parsed as AML, all others as plain CUE.
+func FSToFiles(fsys fs.FS) (result []cue.File, _ error) {
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(path.Ext(name))
+		if ext != ".aml" && ext != ".cue" {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		file := cue.File{
+			Filename:    fsFilename(name),
+			DisplayName: name,
+			Data:        data,
+		}
+		if ext == ".aml" {
+			file.Parser = amlparser.ParseFile
+		}
+		result = append(result, file)
+		return nil
 	})
+	return result, err
+}
+
+// fsFilename returns the name a file found at name in an fs.FS is loaded as.
+// All files are loaded from one directory, so the path is flattened by
+// escaping _ as _5f and / as _2f. The fs_ prefix keeps the name apart from
+// the files the input is loaded with.
+func fsFilename(name string) string {
+	name = strings.TrimSuffix(name, path.Ext(name))
+	name = strings.NewReplacer("_", "_5f", "/", "_2f").Replace(name)
+	return "fs_" + name + ".cue"
+}
+
+// toFiles returns a file declaring the content of the bundled files, keyed by
+// path, in the hidden field read by std.files and the std file functions.
+func toFiles(files map[string]string) (cue.File, error) {
+	data, err := json.Marshal(files)
 	if err != nil {
 		return cue.File{}, err
 	}
 	return cue.File{
 		Filename: "files.cue",
-		Data:     data,
+		Data:     []byte(fmt.Sprintf("%s: %s\n", std.FilesField, data)),
 	}, nil
 }
-
-func addFile(files map[string]any, filename, content string) {
-	parts := strings.Split(filename, "/")
-
-	for i, part := range parts {
-		if i == len(parts)-1 {
-			files[part] = content
-		} else {
-			sub, ok := files[part].(map[string]any)
-			if !ok {
-				sub = map[string]any{}
-				files[part] = sub
-			}
-			files = sub
-		}
-	}
-}
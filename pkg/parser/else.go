@@ -5,6 +5,91 @@ import (
 	"cuelang.org/go/cue/token"
 )
 
+func (p *parser) atElse() bool {
+	return p.tok == token.IDENT && p.lit == "else"
+}
+
+// skipNewlineBeforeElse consumes the comma inserted at the end of a line if
+// the next line starts with "else if" or "else {", so that an else may start
+// on the line after the closing brace of the previous branch.
+func (p *parser) skipNewlineBeforeElse() {
+	if p.tok != token.COMMA || p.lit != "\n" {
+		return
+	}
+	// Scan ahead with a copy of the scanner. Any errors it reports are
+	// reported again when the tokens are scanned by the parser, and the
+	// duplicates are removed.
+	s := p.scanner
+	_, tok, lit := s.Scan()
+	for tok == token.COMMENT {
+		_, tok, lit = s.Scan()
+	}
+	if tok != token.IDENT || lit != "else" {
+		return
+	}
+	if _, tok, _ = s.Scan(); tok == token.IF || tok == token.LBRACE {
+		p.next()
+	}
+}
+
+// parseElseBody parses the branch that follows an already consumed "else"
+// keyword, being either "if cond {...}" or a bare "{...}".
+func (p *parser) parseElseBody() *ast.Comprehension {
+	var clauses []ast.Clause
+	if p.tok == token.IF {
+		clauses, _ = p.parseComprehensionClauses(false)
+	}
+
+	sc := p.openComments()
+	expr := p.parseStruct()
+	sc.closeExpr(p, expr)
+
+	return &ast.Comprehension{
+		Clauses: clauses,
+		Value:   expr,
+	}
+}
+
+// parseElse parses all "else if" and "else" branches following comp and
+// returns the full chain starting with comp.
+func (p *parser) parseElse(comp *ast.Comprehension) (chain []ast.Decl) {
+	chain = append(chain, comp)
+	last := comp
+	for p.skipNewlineBeforeElse(); p.atElse(); p.skipNewlineBeforeElse() {
+		pos := p.pos
+		p.next()
+		if !startsWithIf(last) {
+			if len(last.Clauses) == 0 {
+				p.errf(pos, "else must not follow a final else")
+			} else {
+				p.errf(pos, "else must follow an if clause")
+			}
+		}
+		last = p.parseElseBody()
+		chain = append(chain, last)
+	}
+	return chain
+}
+
+// checkDanglingElse reports an else that does not follow an if comprehension,
+// consuming its body so that parsing can continue.
+func (p *parser) checkDanglingElse(expr ast.Expr) bool {
+	if i, ok := expr.(*ast.Ident); !ok || i.Name != "else" || (p.tok != token.IF && p.tok != token.LBRACE) {
+		return false
+	}
+	p.errf(expr.Pos(), "else without matching if")
+	p.parseElseBody()
+	return true
+}
+
+func startsWithIf(comp *ast.Comprehension) bool {
+	if len(comp.Clauses) == 0 {
+		return false
+	}
+	_, ok := comp.Clauses[0].(*ast.IfClause)
+	return ok
+}
+
 func getOrSetIfClause(decl ast.Decl) *ast.IfClause {
 	comp := decl.(*ast.Comprehension)
 	if len(comp.Clauses) != 0 {
@@ -39,14 +124,13 @@ func not(ifCond ast.Expr) ast.Expr {
 	return &ast.UnaryExpr{
 		OpPos: ifCond.Pos(),
 		Op:    token.NOT,
-		X:     ifCond,
+		X:     &ast.ParenExpr{Lparen: ifCond.Pos(), X: ifCond, Rparen: ifCond.Pos()},
 	}
 }
 
-func buildElse(decls []ast.Decl) ast.Decl {
-	if len(decls) == 1 {
-		return decls[0]
-	}
+// chainElse rewrites the if clause of each comprehension so that exactly one
+// of them applies, the first whose condition is true.
+func chainElse(decls []ast.Decl) {
 	var oldNotCondition ast.Expr
 	for _, decl := range decls {
 		ifCond := getOrSetIfClause(decl)
@@ -54,6 +138,13 @@ func buildElse(decls []ast.Decl) ast.Decl {
 		ifCond.Condition = and(oldNotCondition, ifCond.Condition)
 		oldNotCondition = and(oldNotCondition, newNotCondition)
 	}
+}
+
+func buildElse(decls []ast.Decl) ast.Decl {
+	if len(decls) == 1 {
+		return decls[0]
+	}
+	chainElse(decls)
 	return &ast.EmbedDecl{
 		Expr: &ast.StructLit{
 			Lbrace: decls[0].Pos(),
@@ -62,3 +153,15 @@ func buildElse(decls []ast.Decl) ast.Decl {
 		},
 	}
 }
+
+// buildElseList is the list literal equivalent of buildElse, returning one
+// list element per branch.
+func buildElseList(decls []ast.Decl) (result []ast.Expr) {
+	if len(decls) > 1 {
+		chainElse(decls)
+	}
+	for _, decl := range decls {
+		result = append(result, decl.(*ast.Comprehension))
+	}
+	return result
+}
@@ -744,10 +744,15 @@ func (p *parser) parseCallOrConversion(fun ast.Expr) (expr ast.Expr) {
 						&ast.Field{
 							TokenPos: lparen,
 							Label: &ast.Ident{
-								Name: "_args",
+								NamePos: lparen,
+								Name:    "_args",
 							},
 							Token: token.COLON,
-							Value: ast.NewList(list...),
+							Value: &ast.ListLit{
+								Lbrack: lparen,
+								Elts:   list,
+								Rbrack: rparen,
+							},
 						},
 					},
 					Rbrace: rparen,
@@ -856,14 +861,16 @@ func (p *parser) parseComprehension() (decl ast.Decl, ident *ast.Ident) {
 	expr := p.parseStruct()
 	sc.closeExpr(p, expr)
 
+	decl = buildElse(p.parseElse(&ast.Comprehension{
+		Clauses: clauses,
+		Value:   expr,
+	}))
+
 	if p.atComma("struct literal", token.RBRACE) { // TODO: may be EOF
 		p.next()
 	}
 
-	return &ast.Comprehension{
-		Clauses: clauses,
-		Value:   expr,
-	}, nil
+	return decl, nil
 }
 
 func (p *parser) parseField() (decl ast.Decl) {
@@ -885,6 +892,10 @@ func (p *parser) parseField() (decl ast.Decl) {
 	if decl != nil {
 		return decl
 	}
+	if p.checkDanglingElse(expr) {
+		p.consumeDeclComma()
+		return &ast.BadDecl{From: pos, To: p.pos}
+	}
 	m.Label = label
 
 	if !ok {
@@ -1242,8 +1253,8 @@ func (p *parser) parseListElements() (list []ast.Expr) {
 	defer p.closeList()
 
 	for p.tok != token.RBRACK && p.tok != token.ELLIPSIS && p.tok != token.EOF {
-		expr, ok := p.parseListElement()
-		list = append(list, expr)
+		exprs, ok := p.parseListElement()
+		list = append(list, exprs...)
 		if !ok {
 			break
 		}
@@ -1252,13 +1263,18 @@ func (p *parser) parseListElements() (list []ast.Expr) {
 	return
 }
 
-func (p *parser) parseListElement() (expr ast.Expr, ok bool) {
+func (p *parser) parseListElement() (exprs []ast.Expr, ok bool) {
 	if p.trace {
 		defer un(trace(p, "ListElement"))
 	}
 	c := p.openComments()
-	defer func() { c.closeNode(p, expr) }()
+	defer func() {
+		if len(exprs) > 0 {
+			c.closeNode(p, exprs[0])
+		}
+	}()
 
+	var expr ast.Expr
 	switch p.tok {
 	case token.FOR, token.IF:
 		tok := p.tok
@@ -1269,14 +1285,16 @@ func (p *parser) parseListElement() (expr ast.Expr, ok bool) {
 			expr := p.parseStruct()
 			sc.closeExpr(p, expr)
 
+			exprs = buildElseList(p.parseElse(&ast.Comprehension{
+				Clauses: clauses,
+				Value:   expr,
+			}))
+
 			if p.atComma("list literal", token.RBRACK) { // TODO: may be EOF
 				p.next()
 			}
 
-			return &ast.Comprehension{
-				Clauses: clauses,
-				Value:   expr,
-			}, true
+			return exprs, true
 		}
 
 		expr = &ast.Ident{
@@ -1287,10 +1305,14 @@ func (p *parser) parseListElement() (expr ast.Expr, ok bool) {
 
 	default:
 		expr = p.parseUnaryExpr()
+		if p.checkDanglingElse(expr) {
+			expr = &ast.BadExpr{From: expr.Pos(), To: p.pos}
+		}
 	}
 
 	expr = p.parseBinaryExprTail(token.LowestPrec+1, expr)
 	expr = p.parseAlias(expr)
+	exprs = []ast.Expr{expr}
 
 	// Enforce there is an explicit comma. We could also allow the
 	// omission of commas in lists, but this gives rise to some ambiguities
@@ -1300,15 +1322,15 @@ func (p *parser) parseListElement() (expr ast.Expr, ok bool) {
 		// Allow missing comma for last element, though, to be compliant
 		// with JSON.
 		if p.tok == token.RBRACK || p.tok == token.FOR || p.tok == token.IF {
-			return expr, false
+			return exprs, false
 		}
 		p.errf(p.pos, "missing ',' before newline in list literal")
 	} else if !p.atComma("list literal", token.RBRACK, token.FOR, token.IF) {
-		return expr, false
+		return exprs, false
 	}
 	p.next()
 
-	return expr, true
+	return exprs, true
 }
 
 // parseAlias turns an expression into an alias.
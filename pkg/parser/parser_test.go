@@ -654,6 +654,76 @@ bar: 2
 			}
 			`,
 		out: "frontStyle: {\"key\": \"value\", \"key2\": \"value2\", \"foo\": bar}",
+	}, {
+		desc: "if else",
+		in: `
+			if x {
+				a: 1
+			} else {
+				b: 2
+			}`,
+		out: "{if x {a: 1}, if !(x)&&true {b: 2}}",
+	}, {
+		desc: "if else if else",
+		in: `
+			if x {
+				a: 1
+			} else if y {
+				b: 2
+			} else {
+				c: 3
+			}`,
+		out: "{if x {a: 1}, if !(x)&&y {b: 2}, if !(x)&&!(y)&&true {c: 3}}",
+	}, {
+		desc: "else on the line after the closing brace",
+		in: `
+			if x {
+				a: 1
+			}
+			// otherwise
+			else if y {
+				b: 2
+			}
+			else {
+				c: 3
+			}
+			else: 4`,
+		out: "{if x {a: 1}, if !(x)&&y {b: 2}, if !(x)&&!(y)&&true {c: 3}}, else: 4",
+	}, {
+		desc: "list else on the line after the closing brace",
+		in: `l: [
+				if x {2}
+				else {3},
+			]`,
+		out: "l: [if x {2}, if !(x)&&true {3}]",
+	}, {
+		desc: "list if else",
+		in:   `l: [1, if x {2} else if y {3} else {4}, 5]`,
+		out:  "l: [1, if x {2}, if !(x)&&y {3}, if !(x)&&!(y)&&true {4}, 5]",
+	}, {
+		desc: "dangling else",
+		in: `
+			a: 1
+			else {
+				b: 2
+			}`,
+		out: "a: 1, <*ast.BadDecl>\nelse without matching if",
+	}, {
+		desc: "dangling else in list",
+		in:   `l: [1, else {2}]`,
+		out:  "l: [1, <*ast.BadExpr>]\nelse without matching if",
+	}, {
+		desc: "else after final else",
+		in:   `if x {a: 1} else {b: 2} else {c: 3}`,
+		out:  "{if x {a: 1}, if !(x)&&true {b: 2}, if !(x)&&!(true)&&true {c: 3}}\nelse must not follow a final else",
+	}, {
+		desc: "else after for",
+		in:   `for x in y {a: x} else {b: 2}`,
+		out:  "{if true for x in y {a: x}, if !(true)&&true {b: 2}}\nelse must follow an if clause",
+	}, {
+		desc: "else as label",
+		in:   `else: 1, if x {else: 2}`,
+		out:  "else: 1, if x {else: 2}",
 	}}
 	for _, tc := range testCases {
 		if map[string]bool{
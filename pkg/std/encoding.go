@@ -0,0 +1,321 @@
+package std
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// toINI encodes a struct as an INI file. Fields holding structs become
+// sections, all other fields must be strings, numbers or bools.
+func toINI(args []any) (any, error) {
+	fields, _ := args[0].(map[string]any)
+	buf := &bytes.Buffer{}
+
+	var sections []string
+	for _, key := range sortedKeys(fields) {
+		if _, ok := fields[key].(map[string]any); ok {
+			sections = append(sections, key)
+			continue
+		}
+		if err := writeINIValue(buf, []string{key}, fields[key]); err != nil {
+			return nil, err
+		}
+	}
+	for _, section := range sections {
+		if strings.ContainsAny(section, "[]\n") {
+			return nil, fmt.Errorf("invalid section name %q", section)
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(buf, "[%s]\n", section)
+		values := fields[section].(map[string]any)
+		for _, key := range sortedKeys(values) {
+			if err := writeINIValue(buf, []string{section, key}, values[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf.String(), nil
+}
+
+func writeINIValue(buf *bytes.Buffer, path []string, v any) error {
+	key := path[len(path)-1]
+	if key == "" || strings.ContainsAny(key, "=[];#\n") || strings.TrimSpace(key) != key {
+		return fmt.Errorf("invalid INI key %q", strings.Join(path, "."))
+	}
+	s, err := scalarString(v)
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+	}
+	if strings.ContainsAny(s, "\n\r") {
+		return fmt.Errorf("%s: INI values can not contain newlines", strings.Join(path, "."))
+	}
+	if strings.TrimSpace(s) != s || strings.ContainsAny(s, `;#"`) {
+		s = strconv.Quote(s)
+	}
+	fmt.Fprintf(buf, "%s = %s\n", key, s)
+	return nil
+}
+
+// fromINI decodes an INI file. Keys before the first section are top level
+// fields, sections become structs. All values are strings.
+func fromINI(args []any) (any, error) {
+	s, _ := args[0].(string)
+	result := map[string]any{}
+	current := result
+
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", strings.HasPrefix(line, ";"), strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: expected ] after section name", i+1)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			section, ok := result[name].(map[string]any)
+			if _, exists := result[name]; exists && !ok {
+				return nil, fmt.Errorf("line %d: section %s conflicts with key %s", i+1, name, name)
+			} else if !ok {
+				section = map[string]any{}
+				result[name] = section
+			}
+			current = section
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", i+1)
+		}
+		if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value %s", i+1, value)
+			}
+			value = unquoted
+		} else if len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		if _, ok := current[key].(map[string]any); ok {
+			return nil, fmt.Errorf("line %d: key %s conflicts with section %s", i+1, key, key)
+		}
+		current[key] = value
+	}
+	return result, nil
+}
+
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// toDotenv encodes a struct of strings, numbers and bools as a .env file.
+// Values containing anything but a small set of safe characters are double
+// quoted.
+func toDotenv(args []any) (any, error) {
+	fields, _ := args[0].(map[string]any)
+	buf := &bytes.Buffer{}
+	for _, key := range sortedKeys(fields) {
+		if !envName.MatchString(key) {
+			return nil, fmt.Errorf("invalid environment variable name %q", key)
+		}
+		s, err := scalarString(fields[key])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		fmt.Fprintf(buf, "%s=%s\n", key, dotenvQuote(s))
+	}
+	return buf.String(), nil
+}
+
+var safeDotenvValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+func dotenvQuote(s string) string {
+	if safeDotenvValue.MatchString(s) {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "$", `\$`, "`", "\\`")
+	return `"` + r.Replace(s) + `"`
+}
+
+// fromDotenv decodes a .env file. Lines may start with export, values may be
+// single quoted (literal) or double quoted (with escapes and spanning lines)
+// and unquoted values end at a " #" comment. Variables are not expanded.
+func fromDotenv(args []any) (any, error) {
+	s, _ := args[0].(string)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	result := map[string]any{}
+
+	for line := 1; s != ""; {
+		var text string
+		text, s, _ = strings.Cut(s, "\n")
+		current := line
+		line++
+
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envName.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected NAME=value", current)
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			// Double quoted values may continue on the following lines
+			for closingQuote(value) == -1 {
+				if s == "" {
+					return nil, fmt.Errorf("line %d: unterminated quoted value", current)
+				}
+				var next string
+				next, s, _ = strings.Cut(s, "\n")
+				value += "\n" + next
+				line++
+			}
+			end := closingQuote(value)
+			if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected %q after quoted value", current, rest)
+			}
+			value = unescapeDotenv(value[1:end])
+		case strings.HasPrefix(value, "'"):
+			end := strings.Index(value[1:], "'")
+			if end == -1 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", current)
+			}
+			if rest := strings.TrimSpace(value[end+2:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected %q after quoted value", current, rest)
+			}
+			value = value[1 : end+1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = value[:i]
+			}
+			value = strings.TrimSpace(value)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// closingQuote returns the index of the unescaped double quote ending the
+// quoted string at the start of s, or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDotenv(s string) string {
+	buf := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			buf.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return buf.String()
+}
+
+// fromCSV decodes CSV data into a list of structs, one for each record,
+// using the first record as field names. The optional second argument is the
+// separator, by default a comma.
+func fromCSV(args []any) (any, error) {
+	s, _ := args[0].(string)
+	r := csv.NewReader(strings.NewReader(s))
+	if len(args) > 1 {
+		sep, _ := args[1].(string)
+		c, size := utf8.DecodeRuneInString(sep)
+		if size == 0 || size != len(sep) {
+			return nil, fmt.Errorf("separator must be a single character, got %q", sep)
+		}
+		r.Comma = c
+	}
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	result := []any{}
+	if len(records) == 0 {
+		return result, nil
+	}
+
+	header := records[0]
+	seen := map[string]bool{}
+	for _, name := range header {
+		if seen[name] {
+			return nil, fmt.Errorf("line 1: duplicate column %q", name)
+		}
+		seen[name] = true
+	}
+	for _, record := range records[1:] {
+		row := map[string]any{}
+		for i, value := range record {
+			row[header[i]] = value
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
+
+// scalarString formats a string, number or bool decoded from JSON.
+func scalarString(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return formatNumber(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("value must be a string, number or bool")
+}
+
+func formatNumber(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]any) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+package std
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// format formats its arguments according to the fmt verbs in the format
+// string. Values printed with %v or %s are rendered as in AML, so lists and
+// structs are written as JSON. The other verbs only accept values of the
+// matching kind.
+func format(args []any) (any, error) {
+	f, _ := args[0].(string)
+	values := args[1:]
+
+	var (
+		buf = &strings.Builder{}
+		n   int
+	)
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			buf.WriteByte(f[i])
+			continue
+		}
+		start := i
+		for i++; i < len(f) && strings.IndexByte("+-# 0123456789.", f[i]) >= 0; i++ {
+		}
+		if i == len(f) {
+			return nil, fmt.Errorf("incomplete verb %q at the end of the format", f[start:])
+		}
+		if f[i] == '%' {
+			buf.WriteByte('%')
+			continue
+		}
+		if n == len(values) {
+			return nil, fmt.Errorf("missing argument for %s", f[start:i+1])
+		}
+		value, err := formatValue(f[i], values[n])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %s %w", n+2, f[start:i+1], err)
+		}
+		fmt.Fprintf(buf, f[start:i+1], value)
+		n++
+	}
+	if n < len(values) {
+		return nil, fmt.Errorf("too many arguments, the format uses %d", n)
+	}
+	return buf.String(), nil
+}
+
+// formatValue returns the value to pass to fmt for v, which is printed with
+// verb.
+func formatValue(verb byte, v any) (any, error) {
+	kind := formatKind(v)
+	switch verb {
+	case 'v', 's':
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return render(v)
+	case 'q':
+		if kind == "string" {
+			return v, nil
+		}
+		return nil, fmt.Errorf("requires string, got %s", kind)
+	case 'd', 'b', 'o':
+		if kind == "int" {
+			return int64(v.(float64)), nil
+		}
+		return nil, fmt.Errorf("requires int, got %s", kind)
+	case 'x', 'X':
+		if kind == "int" {
+			return int64(v.(float64)), nil
+		}
+		if kind == "string" {
+			return v, nil
+		}
+		return nil, fmt.Errorf("requires int or string, got %s", kind)
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		if kind == "int" || kind == "float" {
+			return v, nil
+		}
+		return nil, fmt.Errorf("requires number, got %s", kind)
+	case 't':
+		if kind == "bool" {
+			return v, nil
+		}
+		return nil, fmt.Errorf("requires bool, got %s", kind)
+	}
+	return nil, fmt.Errorf("is not supported")
+}
+
+// formatKind returns the AML kind of a value decoded from JSON.
+func formatKind(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "int"
+		}
+		return "float"
+	case []any:
+		return "list"
+	}
+	return "struct"
+}
+
+// render returns the AML rendering of a value decoded from JSON.
+func render(v any) (string, error) {
+	if f, ok := v.(float64); ok {
+		return formatNumber(f), nil
+	}
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
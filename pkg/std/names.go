@@ -0,0 +1,111 @@
+package std
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultDNSNameLength = 63
+	dnsNameHashLength    = 8
+)
+
+var invalidDNSChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// dnsName converts a string into a name matching ^[a-z][-a-z0-9]*$ of at most
+// maxLen characters, 63 by default. Invalid characters are replaced with
+// dashes and names that are too long are truncated and suffixed with a hash of
+// the original string so they stay unique.
+func dnsName(args []any) (any, error) {
+	s, _ := args[0].(string)
+	maxLen := int64(defaultDNSNameLength)
+	if len(args) > 1 {
+		var err error
+		if maxLen, err = toInt(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if maxLen < dnsNameHashLength+2 {
+		return nil, fmt.Errorf("maximum length must be at least %d, got %d", dnsNameHashLength+2, maxLen)
+	}
+
+	name := invalidDNSChars.ReplaceAllString(strings.ToLower(s), "-")
+	name = strings.TrimLeft(name, "-0123456789")
+	name = strings.TrimRight(name, "-")
+	if name == "" {
+		name = "x"
+	}
+	if int64(len(name)) <= maxLen {
+		return name, nil
+	}
+
+	prefix := strings.TrimRight(name[:maxLen-dnsNameHashLength-1], "-")
+	return prefix + "-" + hashString([]byte(s), dnsNameHashLength), nil
+}
+
+// shortHash returns the first n, by default 8, hex characters of the SHA-256
+// hash of value. Strings are hashed as is, all other values as JSON.
+func shortHash(args []any) (any, error) {
+	n := int64(dnsNameHashLength)
+	if len(args) > 1 {
+		var err error
+		if n, err = toInt(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if n < 1 || n > sha256.Size*2 {
+		return nil, fmt.Errorf("length must be between 1 and %d, got %d", sha256.Size*2, n)
+	}
+
+	data, ok := args[0].(string)
+	if !ok {
+		encoded, err := json.Marshal(args[0])
+		if err != nil {
+			return nil, err
+		}
+		data = string(encoded)
+	}
+	return hashString([]byte(data), int(n)), nil
+}
+
+func hashString(data []byte, n int) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:n]
+}
+
+var uuidNamespaces = map[string]string{
+	"dns":  "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	"url":  "6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+	"oid":  "6ba7b812-9dad-11d1-80b4-00c04fd430c8",
+	"x500": "6ba7b814-9dad-11d1-80b4-00c04fd430c8",
+}
+
+// uuidv5 returns the name based UUID of name in namespace, which is a UUID or
+// one of the predefined namespaces dns, url, oid or x500.
+func uuidv5(args []any) (any, error) {
+	namespace, _ := args[0].(string)
+	name, _ := args[1].(string)
+	if ns, ok := uuidNamespaces[strings.ToLower(namespace)]; ok {
+		namespace = ns
+	}
+
+	ns, err := hex.DecodeString(strings.ReplaceAll(namespace, "-", ""))
+	if err != nil || len(ns) != 16 || len(namespace) != 36 {
+		return nil, fmt.Errorf("invalid UUID namespace %q", namespace)
+	}
+
+	h := sha1.New()
+	h.Write(ns)
+	h.Write([]byte(name))
+	u := h.Sum(nil)[:16]
+	u[6] = u[6]&0x0f | 0x50
+	u[8] = u[8]&0x3f | 0x80
+
+	s := hex.EncodeToString(u)
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:], nil
+}
@@ -0,0 +1,308 @@
+package std
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+)
+
+// nativeField is the hidden field holding the results of calls to native
+// functions, keyed by the JSON encoding of the function name and arguments.
+const nativeField = "_std_native"
+
+// Func implements a function registered with Register. The arguments are
+// passed as decoded by encoding/json and the result must be a value
+// encoding/json can encode.
+type Func func(args []any) (any, error)
+
+type native struct {
+	signatures []Signature
+	fn         Func
+}
+
+var (
+	nativeLock sync.Mutex
+	natives    = map[string]native{}
+	// sealed is set once AML is evaluated, after which the library may be
+	// read concurrently and can no longer change
+	sealed bool
+)
+
+// builtins are the std functions implemented in Go rather than in std.cue.
+var builtins = map[string]native{
+	"regexSplit": {[]Signature{{{"string"}, {"string"}}}, regexSplit},
+	"format":     {[]Signature{{{"string"}, {Ellipsis, "_"}}}, format},
+
+	"cidrContains": {[]Signature{{{"string"}, {"string"}}}, cidrContains},
+	"cidrHost":     {[]Signature{{{"string"}, {"int"}}}, cidrHost},
+	"cidrSubnet":   {[]Signature{{{"string"}, {"int"}, {"int"}}}, cidrSubnet},
+
+	"urlParse":    {[]Signature{{{"string"}}}, urlParse},
+	"urlBuild":    {[]Signature{{{"struct"}}}, urlBuild},
+	"queryEncode": {[]Signature{{{"struct"}}}, queryEncode},
+	"queryDecode": {[]Signature{{{"string"}}}, queryDecode},
+
+	"semverParse":     {[]Signature{{{"string"}}}, semverParse},
+	"semverCompare":   {[]Signature{{{"string"}, {"string"}}}, semverCompare},
+	"semverSatisfies": {[]Signature{{{"string"}, {"string"}}}, semverSatisfies},
+	"semverBump":      {[]Signature{{{"string"}, {"string"}}}, semverBump},
+
+	"toINI":      {[]Signature{{{"struct"}}}, toINI},
+	"fromINI":    {[]Signature{{{"string"}}}, fromINI},
+	"toDotenv":   {[]Signature{{{"struct"}}}, toDotenv},
+	"fromDotenv": {[]Signature{{{"string"}}}, fromDotenv},
+	"fromCSV":    {[]Signature{{{"string"}}, {{"string"}, {"string"}}}, fromCSV},
+	"toTOML":     {[]Signature{{{"struct"}}}, toTOML},
+	"fromTOML":   {[]Signature{{{"string"}}}, fromTOML},
+
+	"dnsName":   {[]Signature{{{"string"}}, {{"string"}, {"int"}}}, dnsName},
+	"shortHash": {[]Signature{{{"_"}}, {{"_"}, {"int"}}}, shortHash},
+	"uuidv5":    {[]Signature{{{"string"}, {"string"}}}, uuidv5},
+}
+
+func init() {
+	for name, b := range builtins {
+		if err := Register(name, b.signatures, b.fn); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register adds fn to the std library so it can be called as std.name(...).
+// Calls are checked against signatures in the same way as the functions
+// declared in std.cue; no signatures means any arguments are accepted.
+// Register must be called from an init function. It fails once any AML has
+// been evaluated.
+func Register(name string, signatures []Signature, fn Func) error {
+	if !ast.IsValidIdent(name) || strings.HasPrefix(name, "_") || strings.HasPrefix(name, "#") {
+		return fmt.Errorf("invalid std function name %q", name)
+	}
+	if fn == nil {
+		return fmt.Errorf("std.%s: function is nil", name)
+	}
+	for _, sig := range signatures {
+		for i, param := range sig {
+			if len(param) > 0 && param[0] == Ellipsis && i != len(sig)-1 {
+				return fmt.Errorf("std.%s: only the last parameter may be variadic", name)
+			}
+			if _, err := paramExpr(param); err != nil {
+				return fmt.Errorf("std.%s: %w", name, err)
+			}
+		}
+	}
+
+	nativeLock.Lock()
+	defer nativeLock.Unlock()
+
+	if Library.Functions[name] {
+		return fmt.Errorf("std.%s is already defined", name)
+	}
+	if sealed {
+		return fmt.Errorf("std.%s: functions must be registered before AML is evaluated", name)
+	}
+	natives[name] = native{
+		signatures: signatures,
+		fn:         fn,
+	}
+	if err := load(); err != nil {
+		delete(natives, name)
+		return err
+	}
+	return nil
+}
+
+// withNatives adds the definitions of the registered native functions to the
+// std struct in data. The out field of each function looks up the result of
+// the call in nativeField, which is filled in by Resolve.
+func withNatives(data []byte) ([]byte, error) {
+	if len(natives) == 0 {
+		return data, nil
+	}
+
+	file, err := parser.ParseFile(Filename, data)
+	if err != nil {
+		return nil, err
+	}
+	end := file.Decls[1].(*ast.LetClause).Expr.(*ast.StructLit).Rbrace.Offset()
+
+	var names []string
+	for name := range natives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	for _, name := range names {
+		args, _ := signaturesExpr(natives[name].signatures)
+		fmt.Fprintf(buf, "\n\t%s: {\n\t\t_args: %s\n\t\tout: %s[_std_json.Marshal({name: %q, args: _args})]\n\t}\n",
+			name, args, nativeField, name)
+	}
+
+	result := append([]byte{}, data[:end]...)
+	result = append(result, buf.Bytes()...)
+	result = append(result, data[end:]...)
+	return append(result, fmt.Sprintf("\n%s: {}\n", nativeField)...), nil
+}
+
+func signaturesExpr(sigs []Signature) (string, error) {
+	if len(sigs) == 0 {
+		return "[...]", nil
+	}
+	var alternatives []string
+	for _, sig := range sigs {
+		var params []string
+		for _, param := range sig {
+			expr, err := paramExpr(param)
+			if err != nil {
+				return "", err
+			}
+			params = append(params, expr)
+		}
+		alternatives = append(alternatives, "["+strings.Join(params, ", ")+"]")
+	}
+	return strings.Join(alternatives, " | "), nil
+}
+
+func paramExpr(p Param) (string, error) {
+	if len(p) > 0 && p[0] == Ellipsis {
+		expr, err := paramExpr(p[1:])
+		if len(p) > 2 {
+			expr = "(" + expr + ")"
+		}
+		return Ellipsis + expr, err
+	}
+	if len(p) == 0 {
+		return "_", nil
+	}
+	var exprs []string
+	for _, kind := range p {
+		switch kind {
+		case "string", "bytes", "int", "float", "number", "bool", "null", "_":
+			exprs = append(exprs, kind)
+		case "list":
+			exprs = append(exprs, "[...]")
+		case "struct":
+			exprs = append(exprs, "{...}")
+		default:
+			return "", fmt.Errorf("invalid parameter kind %q", kind)
+		}
+	}
+	return strings.Join(exprs, " | "), nil
+}
+
+type nativeCall struct {
+	Name string `json:"name"`
+	Args []any  `json:"args"`
+}
+
+// Resolve calls the native functions whose results were missing when
+// evaluating value. It returns CUE source declaring the results, or nil if
+// there were no such calls.
+func Resolve(value cue.Value) ([]byte, error) {
+	nativeLock.Lock()
+	sealed = true
+	nativeLock.Unlock()
+
+	// nativeField is only declared if a native function is called, which
+	// saves validating values that do not call any.
+	if !value.LookupPath(cue.MakePath(cue.Hid(nativeField, "_"))).Exists() {
+		return nil, nil
+	}
+
+	var (
+		keys = map[string]cueerrors.Error{}
+		errs cueerrors.Error
+		buf  = &bytes.Buffer{}
+	)
+	for _, err := range cueerrors.Errors(value.Validate(cue.Concrete(true))) {
+		missingCalls(err, keys)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var sorted []string
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	fmt.Fprintf(buf, "%s: {\n", nativeField)
+	for _, key := range sorted {
+		var call nativeCall
+		if err := json.Unmarshal([]byte(key), &call); err != nil {
+			return nil, err
+		}
+
+		result, err := natives[call.Name].fn(call.Args)
+		if err == nil {
+			var data []byte
+			data, err = json.Marshal(result)
+			if err == nil {
+				quoted, _ := json.Marshal(key)
+				fmt.Fprintf(buf, "\t%s: %s\n", quoted, data)
+				continue
+			}
+		}
+		errs = cueerrors.Append(errs, &nativeError{
+			err:  cueerrors.Newf(keys[key].Position(), "error in call to std.%s: %v", call.Name, err),
+			path: keys[key].Path(),
+		})
+	}
+	buf.WriteString("}\n")
+
+	if errs != nil {
+		return nil, errs
+	}
+	return buf.Bytes(), nil
+}
+
+// missingCalls adds the keys of the native calls that err reports as missing
+// from nativeField to keys.
+func missingCalls(err error, keys map[string]cueerrors.Error) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		cueErr, ok := err.(cueerrors.Error)
+		if !ok {
+			continue
+		}
+		format, args := cueErr.Msg()
+		if format != "undefined field: %s" || len(args) != 1 {
+			continue
+		}
+		key, uerr := literal.Unquote(fmt.Sprint(args[0]))
+		if uerr != nil {
+			continue
+		}
+		var call nativeCall
+		if json.Unmarshal([]byte(key), &call) != nil {
+			continue
+		}
+		if _, ok := natives[call.Name]; ok {
+			keys[key] = cueErr
+		}
+	}
+}
+
+// nativeError is an error returned by a native function, reported at the path
+// of the value that called it.
+type nativeError struct {
+	err  cueerrors.Error
+	path []string
+}
+
+func (e *nativeError) Position() token.Pos          { return e.err.Position() }
+func (e *nativeError) InputPositions() []token.Pos  { return e.err.InputPositions() }
+func (e *nativeError) Error() string                { return e.err.Error() }
+func (e *nativeError) Path() []string               { return e.path }
+func (e *nativeError) Msg() (string, []interface{}) { return e.err.Msg() }
@@ -0,0 +1,114 @@
+package std
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+)
+
+// cidrContains reports whether the prefix in args[0] contains the address or
+// prefix in args[1].
+func cidrContains(args []any) (any, error) {
+	prefix, err := parsePrefix(args[0])
+	if err != nil {
+		return nil, err
+	}
+	s, _ := args[1].(string)
+	if other, err := netip.ParsePrefix(s); err == nil {
+		return other.Bits() >= prefix.Bits() && prefix.Contains(other.Addr()), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return nil, err
+	}
+	return prefix.Contains(addr), nil
+}
+
+// cidrHost returns the address of host number args[1] in the prefix args[0].
+// Negative numbers count back from the end of the prefix.
+func cidrHost(args []any) (any, error) {
+	prefix, err := parsePrefix(args[0])
+	if err != nil {
+		return nil, err
+	}
+	num, err := toInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(hostBits))
+	host := big.NewInt(num)
+	if num < 0 {
+		host.Add(host, size)
+	}
+	if host.Sign() < 0 || host.Cmp(size) >= 0 {
+		return nil, fmt.Errorf("prefix of %d bits has no host number %d", prefix.Bits(), num)
+	}
+
+	addr, err := addInt(prefix.Addr(), host)
+	if err != nil {
+		return nil, err
+	}
+	return addr.String(), nil
+}
+
+// cidrSubnet returns subnet number args[2] of the prefix args[0] extended by
+// args[1] bits.
+func cidrSubnet(args []any) (any, error) {
+	prefix, err := parsePrefix(args[0])
+	if err != nil {
+		return nil, err
+	}
+	newBits, err := toInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	num, err := toInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	bits := prefix.Bits() + int(newBits)
+	if newBits < 0 || bits > prefix.Addr().BitLen() {
+		return nil, fmt.Errorf("can not extend prefix of %d bits by %d bits", prefix.Bits(), newBits)
+	}
+	if num < 0 || big.NewInt(num).Cmp(new(big.Int).Lsh(big.NewInt(1), uint(newBits))) >= 0 {
+		return nil, fmt.Errorf("prefix extended by %d bits has no subnet number %d", newBits, num)
+	}
+
+	offset := new(big.Int).Lsh(big.NewInt(num), uint(prefix.Addr().BitLen()-bits))
+	addr, err := addInt(prefix.Addr(), offset)
+	if err != nil {
+		return nil, err
+	}
+	return netip.PrefixFrom(addr, bits).String(), nil
+}
+
+func parsePrefix(v any) (netip.Prefix, error) {
+	s, _ := v.(string)
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return prefix, err
+	}
+	return prefix.Masked(), nil
+}
+
+func toInt(v any) (int64, error) {
+	f, ok := v.(float64)
+	if !ok || f != math.Trunc(f) || math.Abs(f) > math.MaxInt64 {
+		return 0, fmt.Errorf("%v is not an integer", v)
+	}
+	return int64(f), nil
+}
+
+func addInt(addr netip.Addr, n *big.Int) (netip.Addr, error) {
+	sum := new(big.Int).Add(new(big.Int).SetBytes(addr.AsSlice()), n)
+	buf := make([]byte, addr.BitLen()/8)
+	if sum.BitLen() > len(buf)*8 {
+		return netip.Addr{}, fmt.Errorf("address out of range")
+	}
+	result, _ := netip.AddrFromSlice(sum.FillBytes(buf))
+	return result, nil
+}
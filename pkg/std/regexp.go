@@ -0,0 +1,17 @@
+package std
+
+import (
+	"regexp"
+)
+
+// regexSplit splits s around the matches of the regular expression re. An
+// empty expression splits s into its characters.
+func regexSplit(args []any) (any, error) {
+	s, _ := args[0].(string)
+	re, _ := args[1].(string)
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return nil, err
+	}
+	return r.Split(s, -1), nil
+}
@@ -0,0 +1,136 @@
+package std
+
+import (
+	"cuelang.org/go/cue/ast"
+)
+
+// uses records what a function in std.cue references outside of its own
+// definition.
+type uses struct {
+	functions  []string
+	imports    []*ast.ImportSpec
+	decls      []ast.Decl
+	unresolved []*ast.Ident
+}
+
+func findUses(file *ast.File, fields []*ast.Field) map[string]*uses {
+	var (
+		result     = map[string]*uses{}
+		functions  = map[ast.Node]string{}
+		decls      = map[ast.Node]ast.Decl{}
+		unresolved = map[*ast.Ident]bool{}
+	)
+	for _, f := range fields {
+		functions[f.Value] = f.Label.(*ast.Ident).Name
+	}
+	for _, decl := range file.Decls {
+		if f, ok := decl.(*ast.Field); ok {
+			decls[f.Value] = f
+		}
+	}
+	for _, i := range file.Unresolved {
+		unresolved[i] = true
+	}
+
+	for _, f := range fields {
+		u := &uses{}
+		seen := map[ast.Node]bool{}
+		ast.Walk(f.Value, func(node ast.Node) bool {
+			i, ok := node.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if unresolved[i] {
+				u.unresolved = append(u.unresolved, i)
+			}
+			if i.Node == nil || seen[i.Node] {
+				return true
+			}
+			seen[i.Node] = true
+			if spec, ok := i.Node.(*ast.ImportSpec); ok {
+				u.imports = append(u.imports, spec)
+			} else if name, ok := functions[i.Node]; ok {
+				u.functions = append(u.functions, name)
+			} else if decl, ok := decls[i.Node]; ok {
+				u.decls = append(u.decls, decl)
+			}
+			return true
+		}, nil)
+		result[f.Label.(*ast.Ident).Name] = u
+	}
+
+	return result
+}
+
+// Select returns the declarations, and the imports they require, needed to
+// call the named functions, including the functions they call. The result
+// declares std with only those functions.
+func (d *Def) Select(names ...string) (imports []*ast.ImportSpec, decls []ast.Decl, unresolved []*ast.Ident) {
+	needed := map[string]bool{}
+	for len(names) > 0 {
+		name := names[0]
+		names = names[1:]
+		if needed[name] || d.uses[name] == nil {
+			continue
+		}
+		needed[name] = true
+		names = append(names, d.uses[name].functions...)
+	}
+
+	var (
+		std        = &ast.StructLit{}
+		seenImport = map[*ast.ImportSpec]bool{}
+		seenDecl   = map[ast.Decl]bool{}
+		extra      []ast.Decl
+	)
+	if s, ok := d.let.Expr.(*ast.StructLit); ok {
+		std.Lbrace, std.Rbrace = s.Lbrace, s.Rbrace
+	}
+
+	for _, f := range d.fields {
+		name := f.Label.(*ast.Ident).Name
+		if !needed[name] {
+			continue
+		}
+		std.Elts = append(std.Elts, f)
+
+		u := d.uses[name]
+		unresolved = append(unresolved, u.unresolved...)
+		for _, spec := range u.imports {
+			seenImport[spec] = true
+		}
+		for _, decl := range u.decls {
+			if !seenDecl[decl] {
+				seenDecl[decl] = true
+				extra = append(extra, decl)
+			}
+		}
+	}
+
+	if len(std.Elts) == 0 {
+		return nil, nil, nil
+	}
+
+	// Keep the imports in the order they are declared in std.cue
+	for _, spec := range d.Imports {
+		if seenImport[spec] {
+			imports = append(imports, spec)
+		}
+	}
+	if len(imports) > 0 {
+		decls = append(decls, &ast.ImportDecl{
+			Import: d.importDecl.Import,
+			Lparen: d.importDecl.Lparen,
+			Specs:  imports,
+			Rparen: d.importDecl.Rparen,
+		})
+	}
+
+	decls = append(decls, &ast.LetClause{
+		Let:   d.let.Let,
+		Ident: d.let.Ident,
+		Equal: d.let.Equal,
+		Expr:  std,
+	})
+	return imports, append(decls, extra...), unresolved
+}
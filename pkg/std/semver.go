@@ -0,0 +1,271 @@
+package std
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type version struct {
+	prefix     string
+	major      int64
+	minor      int64
+	patch      int64
+	prerelease []string
+	build      string
+	// parts is the number of the major, minor and patch numbers given, which
+	// is less than 3 for partial versions in constraints
+	parts int
+}
+
+func (v version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.prefix, v.major, v.minor, v.patch)
+	if len(v.prerelease) > 0 {
+		s += "-" + strings.Join(v.prerelease, ".")
+	}
+	if v.build != "" {
+		s += "+" + v.build
+	}
+	return s
+}
+
+func parseVersion(s string, partial bool) (v version, err error) {
+	rest := strings.TrimSpace(s)
+	if strings.HasPrefix(rest, "v") {
+		v.prefix, rest = "v", rest[1:]
+	}
+	rest, v.build, _ = strings.Cut(rest, "+")
+	rest, pre, hasPre := strings.Cut(rest, "-")
+	if hasPre {
+		v.prerelease = strings.Split(pre, ".")
+		for _, id := range v.prerelease {
+			if id == "" {
+				return v, fmt.Errorf("invalid semantic version %q", s)
+			}
+		}
+	}
+
+	nums := strings.Split(rest, ".")
+	if len(nums) > 3 || (!partial && len(nums) != 3) {
+		return v, fmt.Errorf("invalid semantic version %q", s)
+	}
+	for i, num := range nums {
+		if partial && (num == "x" || num == "X" || num == "*") {
+			break
+		}
+		n, err := strconv.ParseInt(num, 10, 64)
+		if err != nil || n < 0 || (len(num) > 1 && num[0] == '0') {
+			return v, fmt.Errorf("invalid semantic version %q", s)
+		}
+		switch i {
+		case 0:
+			v.major = n
+		case 1:
+			v.minor = n
+		case 2:
+			v.patch = n
+		}
+		v.parts++
+	}
+	return v, nil
+}
+
+func compareVersions(a, b version) int {
+	for _, d := range []int64{a.major - b.major, a.minor - b.minor, a.patch - b.patch} {
+		if d < 0 {
+			return -1
+		} else if d > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case len(a.prerelease) == 0 && len(b.prerelease) == 0:
+		return 0
+	case len(a.prerelease) == 0:
+		return 1
+	case len(b.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.prerelease) && i < len(b.prerelease); i++ {
+		if c := compareIdentifiers(a.prerelease[i], b.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(int64(len(a.prerelease)), int64(len(b.prerelease)))
+}
+
+// compareIdentifiers compares prerelease identifiers, numeric identifiers
+// having lower precedence than alphanumeric ones.
+func compareIdentifiers(a, b string) int {
+	an, aErr := strconv.ParseInt(a, 10, 64)
+	bn, bErr := strconv.ParseInt(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInts(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func semverParse(args []any) (any, error) {
+	s, _ := args[0].(string)
+	v, err := parseVersion(s, false)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"major":      v.major,
+		"minor":      v.minor,
+		"patch":      v.patch,
+		"prerelease": strings.Join(v.prerelease, "."),
+		"build":      v.build,
+	}, nil
+}
+
+func semverCompare(args []any) (any, error) {
+	var versions []version
+	for _, arg := range args {
+		s, _ := arg.(string)
+		v, err := parseVersion(s, false)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return compareVersions(versions[0], versions[1]), nil
+}
+
+// semverSatisfies reports whether a version matches a constraint such as
+// ">=1.2.0, <2.0.0 || ^3.1". Comparisons separated by commas or spaces must
+// all match, alternatives are separated by ||. The operators are =, !=, >,
+// >=, <, <=, ~ (same minor version) and ^ (same major version). Missing minor
+// and patch numbers, or x and *, match any number.
+func semverSatisfies(args []any) (any, error) {
+	s, _ := args[0].(string)
+	v, err := parseVersion(s, false)
+	if err != nil {
+		return nil, err
+	}
+	constraint, _ := args[1].(string)
+
+	for _, alternative := range strings.Split(constraint, "||") {
+		var (
+			matched = true
+			op      string
+		)
+		for _, c := range strings.FieldsFunc(alternative, func(r rune) bool { return r == ',' || r == ' ' }) {
+			// An operator may be separated from its version by spaces
+			if strings.Trim(c, "=!<>~^") == "" {
+				op += c
+				continue
+			}
+			ok, err := satisfies(v, op+c)
+			if err != nil {
+				return nil, err
+			}
+			matched, op = matched && ok, ""
+		}
+		if op != "" {
+			return nil, fmt.Errorf("invalid constraint %q", constraint)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func satisfies(v version, constraint string) (bool, error) {
+	i := strings.IndexFunc(constraint, func(r rune) bool { return !strings.ContainsRune("=!<>~^", r) })
+	if i == -1 {
+		return false, fmt.Errorf("invalid constraint %q", constraint)
+	}
+	op := constraint[:i]
+	c, err := parseVersion(constraint[i:], true)
+	if err != nil {
+		return false, fmt.Errorf("invalid constraint %q", constraint)
+	}
+	if c.parts == 0 {
+		return true, nil
+	}
+
+	// Versions matching c are at least c and less than upper. Complete
+	// versions without ~ or ^ have no upper bound as they match exactly.
+	var upper *version
+	switch {
+	case op == "~" && c.parts > 1, op != "^" && op != "~" && c.parts == 2:
+		upper = &version{major: c.major, minor: c.minor + 1}
+	case op == "^" && c.major == 0 && c.minor == 0 && c.parts == 3:
+		upper = &version{patch: c.patch + 1}
+	case op == "^" && c.major == 0 && c.parts > 1:
+		upper = &version{minor: c.minor + 1}
+	case op == "^", op == "~", c.parts == 1:
+		upper = &version{major: c.major + 1}
+	}
+
+	switch op {
+	case "", "=", "==", "~", "^":
+		if upper == nil {
+			return compareVersions(v, c) == 0, nil
+		}
+		return compareVersions(v, c) >= 0 && compareVersions(v, *upper) < 0, nil
+	case "!=":
+		if upper == nil {
+			return compareVersions(v, c) != 0, nil
+		}
+		return compareVersions(v, c) < 0 || compareVersions(v, *upper) >= 0, nil
+	case ">":
+		if upper == nil {
+			return compareVersions(v, c) > 0, nil
+		}
+		return compareVersions(v, *upper) >= 0, nil
+	case ">=":
+		return compareVersions(v, c) >= 0, nil
+	case "<":
+		return compareVersions(v, c) < 0, nil
+	case "<=":
+		if upper == nil {
+			return compareVersions(v, c) <= 0, nil
+		}
+		return compareVersions(v, *upper) < 0, nil
+	}
+	return false, fmt.Errorf("invalid constraint %q", constraint)
+}
+
+// semverBump increments the major, minor or patch number of a version,
+// resetting the numbers after it and dropping any prerelease and build.
+func semverBump(args []any) (any, error) {
+	s, _ := args[0].(string)
+	v, err := parseVersion(s, false)
+	if err != nil {
+		return nil, err
+	}
+
+	switch part, _ := args[1].(string); part {
+	case "major":
+		v.major, v.minor, v.patch = v.major+1, 0, 0
+	case "minor":
+		v.minor, v.patch = v.minor+1, 0
+	case "patch":
+		v.patch++
+	default:
+		return nil, fmt.Errorf("invalid version part %q, must be major, minor or patch", part)
+	}
+	v.prerelease, v.build = nil, ""
+	return v.String(), nil
+}
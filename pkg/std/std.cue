@@ -13,6 +13,8 @@ import (
 	_std_strconv "strconv"
 	_std_tabwriter "text/tabwriter"
 	_std_math "math"
+	_std_regexp "regexp"
+	_std_template "text/template"
 )
 
 let std = {
@@ -101,6 +103,24 @@ let std = {
 		out: _std_hex.Encode(_std_sha512.Sum512(_args[0]))
 	}
 
+	parseIP: {
+		_args: [string]
+		out: string
+		out: _std_net.IPString(_std_net.ParseIP(_args[0]))
+	}
+
+	isIPv4: {
+		_args: [string]
+		out: bool
+		out: _std_net.IP(_args[0]) && _std_net.IPv4(_args[0])
+	}
+
+	isIPv6: {
+		_args: [string]
+		out: bool
+		out: _std_net.IP(_args[0]) && !_std_net.IPv4(_args[0])
+	}
+
 	toHex: {
 		_args: [bytes | string]
 		out: string
@@ -192,6 +212,159 @@ let std = {
 		}
 	}
 
+	map: {
+		_args: [[...], {
+			x:   _
+			out: _
+		}]
+		out: [...]
+		out: [ for v in _args[0] {
+			(_args[1] & {x: v}).out
+		}]
+	}
+
+	filter: {
+		_args: [[...], {
+			x:    _
+			keep: bool
+		}]
+		out: [...]
+		out: [ for v in _args[0] if (_args[1] & {x: v}).keep {
+			v
+		}]
+	}
+
+	reduce: {
+		_args: [[...], _, {
+			acc: _
+			x:   _
+			out: _
+		}]
+		_acc: [_args[1], for i, v in _args[0] {
+			(_args[2] & {acc: _acc[i], x: v}).out
+		}]
+		out: _acc[len(_acc)-1]
+	}
+
+	flatten: {
+		_args: [[...], int] | [[...]]
+		out: [...]
+		if len(_args) == 1 {
+			out: _std_list.FlattenN(_args[0], -1)
+		}
+		if len(_args) == 2 {
+			out: _std_list.FlattenN(_args[0], _args[1])
+		}
+	}
+
+	unique: {
+		_args: [[...]]
+		out: [...]
+		// Numbers are compared by value, so 1 and 1.0 are the same
+		out: [ for i, v in _args[0]
+			let before = _std_list.Slice(_args[0], 0, i)
+			if !_std_list.Contains(before, v)
+			if len([ for u in before if (u & number) != _|_ if (v & number) != _|_ if u == v {u}]) == 0 {
+				v
+			}]
+	}
+
+	groupBy: {
+		_args: [[...], {
+			x:   _
+			key: string
+		}]
+		_keys: [ for v in _args[0] {
+			(_args[1] & {x: v}).key
+		}]
+		out: {
+			for k in _keys {
+				"\(k)": [ for i, v in _args[0] if _keys[i] == k {
+					v
+				}]
+			}
+		}
+	}
+
+	min: {
+		_args: [[...number]] | [number, ...number]
+		out: number
+		if (_args[0] & [...]) != _|_ {
+			out: _std_list.Min(_args[0])
+		}
+		if (_args[0] & number) != _|_ {
+			out: _std_list.Min(_args)
+		}
+	}
+
+	max: {
+		_args: [[...number]] | [number, ...number]
+		out: number
+		if (_args[0] & [...]) != _|_ {
+			out: _std_list.Max(_args[0])
+		}
+		if (_args[0] & number) != _|_ {
+			out: _std_list.Max(_args)
+		}
+	}
+
+	sum: {
+		_args: [[...number]] | [number, ...number]
+		out: number
+		if (_args[0] & [...]) != _|_ {
+			out: _std_list.Sum(_args[0])
+		}
+		if (_args[0] & number) != _|_ {
+			out: _std_list.Sum(_args)
+		}
+	}
+
+	abs: {
+		_args: [number] | [[...number]]
+		if (_args[0] & number) != _|_ {
+			out: _std_math.Abs(_args[0])
+		}
+		if (_args[0] & [...]) != _|_ {
+			out: [ for v in _args[0] {_std_math.Abs(v)}]
+		}
+	}
+
+	ceil: {
+		_args: [number] | [[...number]]
+		if (_args[0] & number) != _|_ {
+			out: _std_math.Ceil(_args[0])
+		}
+		if (_args[0] & [...]) != _|_ {
+			out: [ for v in _args[0] {_std_math.Ceil(v)}]
+		}
+	}
+
+	floor: {
+		_args: [number] | [[...number]]
+		if (_args[0] & number) != _|_ {
+			out: _std_math.Floor(_args[0])
+		}
+		if (_args[0] & [...]) != _|_ {
+			out: [ for v in _args[0] {_std_math.Floor(v)}]
+		}
+	}
+
+	round: {
+		_args: [number] | [[...number]]
+		if (_args[0] & number) != _|_ {
+			out: _std_math.Round(_args[0])
+		}
+		if (_args[0] & [...]) != _|_ {
+			out: [ for v in _args[0] {_std_math.Round(v)}]
+		}
+	}
+
+	clamp: {
+		_args: [number, number, number]
+		out: number
+		out: _std_list.Min([_std_list.Max([_args[0], _args[1]]), _args[2]])
+	}
+
 	toTitle: {
 		_args: [string]
 		out: string
@@ -302,6 +475,92 @@ let std = {
 		}
 	}
 
+	template: {
+		_args: [string, _]
+		out: string
+		out: _std_template.Execute(_args[0], _args[1])
+	}
+
+	// files holds the content of the files bundled with the AML source, keyed
+	// by path
+	files: _std_files
+
+	// A missing file is reported by unifying a message with null, which the
+	// diagnostics show as just the message
+	readYAML: {
+		_args: [string]
+		if _std_files[_args[0]] == _|_ {
+			_missing: "file not found: \(_args[0])" & null
+		}
+		out: _std_yaml.Unmarshal(_std_files[_args[0]])
+	}
+
+	readJSON: {
+		_args: [string]
+		if _std_files[_args[0]] == _|_ {
+			_missing: "file not found: \(_args[0])" & null
+		}
+		out: _std_json.Unmarshal(_std_files[_args[0]])
+	}
+
+	glob: {
+		_args: [string]
+		out: [...string]
+		// path.Match can not be called in this version of CUE, so the pattern
+		// is converted to a regular expression. ** also matches across
+		// directories.
+		_escaped: _std_regexp.ReplaceAll(#"[.+^$(){}|\\]"#, _args[0], #"\$0"#)
+		_pattern: _std_strings.Replace(_std_strings.Replace(_std_strings.Replace(_escaped, "**", "\u0000", -1), "*", "[^/]*", -1), "?", "[^/]", -1)
+		_regexp:  "^" + _std_strings.Replace(_pattern, "\u0000", ".*", -1) + "$"
+		out: _std_list.SortStrings([ for p, _ in _std_files if _std_regexp.Match(_regexp, p) {p}])
+	}
+
+	renderFile: {
+		_args: [string, _]
+		out: string
+		out: _std_template.Execute(_std_files[_args[0]], _args[1])
+	}
+
+	match: {
+		_args: [string, string]
+		out: bool
+		out: _std_regexp.Match(_args[1], _args[0])
+	}
+
+	find: {
+		_args: [string, string]
+		out:    string
+		_match: _std_regexp.Match(_args[1], _args[0])
+		if _match {
+			out: _std_regexp.Find(_args[1], _args[0])
+		}
+		if !_match {
+			out: ""
+		}
+	}
+
+	findAll: {
+		_args: [string, string, int] | [string, string]
+		out: [...string]
+		_n:  *-1 | int
+		if len(_args) == 3 {
+			_n: _args[2]
+		}
+		_match: _std_regexp.Match(_args[1], _args[0]) && _n != 0
+		if _match {
+			out: _std_regexp.FindAll(_args[1], _args[0], _n)
+		}
+		if !_match {
+			out: []
+		}
+	}
+
+	regexReplace: {
+		_args: [string, string, string]
+		out: string
+		out: _std_regexp.ReplaceAll(_args[1], _args[0], _args[2])
+	}
+
 	merge: {
 		_args: [{}, {}]
 		out: {}
@@ -335,4 +594,124 @@ let std = {
 		}
 	}
 
-}
\ No newline at end of file
+	deepMerge: {
+		_args: [{}, {}, {...}] | [{}, {}]
+		out: {}
+
+		// lists is one of replace, append or merge. Lists merged by key
+		// combine the structs with the same value for the key field and
+		// append all other elements.
+		_options: {
+			lists: *"replace" | "append" | "merge"
+			key:   *"name" | string
+		}
+		if len(_args) == 3 {
+			_options: _args[2]
+		}
+
+		_list: {
+			l: [...]
+			r: [...]
+			out: [...]
+			if _options.lists == "replace" {
+				out: r
+			}
+			if _options.lists == "append" {
+				out: _std_list.Concat([l, r])
+			}
+			if _options.lists == "merge" {
+				let key = _options.key
+				let lkeys = [ for x in l if (x & {}) != _|_ if x[key] != _|_ {x[key]}]
+				let matched = [ for i, y in r if (y & {}) != _|_ if y[key] != _|_ if _std_list.Contains(lkeys, y[key]) {i}]
+				out: _std_list.Concat([[ for x in l {
+					let matches = [ for y in r if (x & {}) != _|_ if (y & {}) != _|_ if x[key] != _|_ if y[key] != _|_ if x[key] == y[key] {y}]
+					if len(matches) > 0 {
+						(deepMerge & {_args: [x, matches[0], _options]}).out
+					}
+					if len(matches) == 0 {
+						x
+					}
+				}], [ for i, y in r if !_std_list.Contains(matched, i) {y}]])
+			}
+		}
+
+		let left = _args[0]
+		let right = _args[1]
+		out: {
+			for k, lv in left {
+				let rv = right[k]
+				if rv != _|_ {
+					if (lv & {}) != _|_ && (rv & {}) != _|_ {
+						"\(k)": (deepMerge & {_args: [lv, rv, _options]}).out
+					}
+					if (lv & [...]) != _|_ && (rv & [...]) != _|_ {
+						"\(k)": (_list & {l: lv, r: rv}).out
+					}
+					if !((lv & {}) != _|_ && (rv & {}) != _|_) && !((lv & [...]) != _|_ && (rv & [...]) != _|_) {
+						"\(k)": rv
+					}
+				}
+				if !(rv != _|_) {
+					"\(k)": lv
+				}
+			}
+			for k, v in right {
+				if !(left[k] != _|_) {
+					"\(k)": v
+				}
+			}
+		}
+	}
+
+	keys: {
+		_args: [{}]
+		out: [...string]
+		out: _std_list.SortStrings([ for k, _ in _args[0] {k}])
+	}
+
+	values: {
+		_args: [{}]
+		out: [...]
+		let obj = _args[0]
+		out: [ for k in (keys & {_args: [obj]}).out {obj[k]}]
+	}
+
+	items: {
+		_args: [{}]
+		out: [...{key: string, value: _}]
+		let obj = _args[0]
+		out: [ for k in (keys & {_args: [obj]}).out {
+			key:   k
+			value: obj[k]
+		}]
+	}
+
+	pick: {
+		_args: [{}, [...string]]
+		out: {}
+		out: {
+			for k, v in _args[0] if _std_list.Contains(_args[1], k) {
+				"\(k)": v
+			}
+		}
+	}
+
+	omit: {
+		_args: [{}, [...string]]
+		out: {}
+		out: {
+			for k, v in _args[0] if !_std_list.Contains(_args[1], k) {
+				"\(k)": v
+			}
+		}
+	}
+
+	hasKey: {
+		_args: [{}, string]
+		out: bool
+		out: _args[0][_args[1]] != _|_
+	}
+
+}
+
+_std_files: {}
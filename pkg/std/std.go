@@ -2,11 +2,19 @@ package std
 
 import (
 	"embed"
+	"strings"
 
 	"cuelang.org/go/cue/ast"
 	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
 )
 
+const Filename = "std.cue"
+
+// FilesField is the hidden field holding the content of the files bundled with
+// the AML source, keyed by path.
+const FilesField = "_std_files"
+
 var (
 	//go:embed std.cue
 	fs      embed.FS
@@ -18,24 +26,175 @@ type Def struct {
 	Unresolved []*ast.Ident
 	Decls      []ast.Decl
 	Functions  map[string]bool
+	// Signatures are the argument lists accepted by each function, taken from
+	// the disjunction declared as its _args field
+	Signatures map[string][]Signature
+
+	fields     []*ast.Field
+	importDecl *ast.ImportDecl
+	let        *ast.LetClause
+	uses       map[string]*uses
+}
+
+// Signature is one argument list accepted by a function.
+type Signature []Param
+
+// Ellipsis is the first kind of the last Param of a variadic Signature, as
+// declared by a trailing ... in an _args list.
+const Ellipsis = "..."
+
+// Variadic reports whether the last Param of s accepts any number of
+// arguments.
+func (s Signature) Variadic() bool {
+	return len(s) > 0 && len(s[len(s)-1]) > 0 && s[len(s)-1][0] == Ellipsis
+}
+
+// AcceptsCount reports whether s accepts n arguments.
+func (s Signature) AcceptsCount(n int) bool {
+	if s.Variadic() {
+		return n >= len(s)-1
+	}
+	return n == len(s)
+}
+
+// Param returns the Param of argument i.
+func (s Signature) Param(i int) Param {
+	if s.Variadic() && i >= len(s)-1 {
+		return s[len(s)-1][1:]
+	}
+	return s[i]
+}
+
+// Param lists the kinds of values accepted as an argument, using the names
+// string, bytes, int, float, number, bool, null, list, struct and _ for any.
+type Param []string
+
+// Accepts reports whether a value of the given kind may be passed as p. A
+// kind of _, meaning unknown, is always accepted.
+func (p Param) Accepts(kind string) bool {
+	if kind == "_" {
+		return true
+	}
+	for _, k := range p {
+		if k == "_" || k == kind || (k == "number" && (kind == "int" || kind == "float")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Param) String() string {
+	if len(p) > 0 && p[0] == Ellipsis {
+		return Ellipsis + p[1:].String()
+	}
+	return strings.Join(p, " or ")
+}
+
+func signatures(f *ast.Field) (result []Signature) {
+	s, ok := f.Value.(*ast.StructLit)
+	if !ok {
+		return nil
+	}
+	for _, e := range s.Elts {
+		if f, ok := e.(*ast.Field); ok {
+			if name, _, _ := ast.LabelName(f.Label); name == "_args" {
+				return alternatives(f.Value)
+			}
+		}
+	}
+	return nil
+}
+
+func alternatives(expr ast.Expr) (result []Signature) {
+	switch v := expr.(type) {
+	case *ast.BinaryExpr:
+		if v.Op == token.OR {
+			return append(alternatives(v.X), alternatives(v.Y)...)
+		}
+	case *ast.ListLit:
+		sig := Signature{}
+		for _, e := range v.Elts {
+			sig = append(sig, kinds(e))
+		}
+		return []Signature{sig}
+	}
+	return nil
+}
+
+func kinds(expr ast.Expr) Param {
+	switch v := expr.(type) {
+	case *ast.Ident:
+		return Param{v.Name}
+	case *ast.BinaryExpr:
+		if v.Op == token.OR {
+			return append(kinds(v.X), kinds(v.Y)...)
+		}
+	case *ast.ListLit:
+		return Param{"list"}
+	case *ast.StructLit:
+		return Param{"struct"}
+	case *ast.ParenExpr:
+		return kinds(v.X)
+	case *ast.Ellipsis:
+		return append(Param{Ellipsis}, kinds(v.Type)...)
+	}
+	return Param{"_"}
+}
+
+// FunctionAt returns the name of the function whose definition in std.cue
+// contains pos.
+func (d *Def) FunctionAt(pos token.Pos) (string, bool) {
+	if !pos.IsValid() || pos.Filename() != Filename {
+		return "", false
+	}
+	for _, f := range d.fields {
+		if f.Pos().Offset() <= pos.Offset() && pos.Offset() < f.End().Offset() {
+			return f.Label.(*ast.Ident).Name, true
+		}
+	}
+	return "", false
 }
 
 func init() {
-	data, err := fs.ReadFile("std.cue")
-	if err != nil {
+	if err := load(); err != nil {
 		panic(err)
 	}
-	stdData, err := parser.ParseFile("std.cue", data)
+}
+
+// load parses std.cue, along with the definitions of the registered native
+// functions, into Library.
+func load() error {
+	data, err := fs.ReadFile(Filename)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	data, err = withNatives(data)
+	if err != nil {
+		return err
+	}
+	stdData, err := parser.ParseFile(Filename, data)
+	if err != nil {
+		return err
+	}
+
+	lib := Def{
+		Functions:  map[string]bool{},
+		Signatures: map[string][]Signature{},
 	}
-	functions := map[string]bool{}
 	for _, e := range stdData.Decls[1].(*ast.LetClause).Expr.(*ast.StructLit).Elts {
-		functions[e.(*ast.Field).Label.(*ast.Ident).Name] = true
+		f := e.(*ast.Field)
+		name := f.Label.(*ast.Ident).Name
+		lib.Functions[name] = true
+		lib.Signatures[name] = signatures(f)
+		lib.fields = append(lib.fields, f)
 	}
 
-	Library.Imports = stdData.Imports
-	Library.Unresolved = stdData.Unresolved
-	Library.Decls = stdData.Decls
-	Library.Functions = functions
+	lib.Imports = stdData.Imports
+	lib.Unresolved = stdData.Unresolved
+	lib.Decls = stdData.Decls
+	lib.importDecl = stdData.Decls[0].(*ast.ImportDecl)
+	lib.let = stdData.Decls[1].(*ast.LetClause)
+	lib.uses = findUses(stdData, lib.fields)
+	Library = lib
+	return nil
 }
@@ -67,6 +67,91 @@ localData: {
 	sort2: std.sort([2, 5, 4])
 	sort2: [2, 4, 5]
 
+	format: std.format("%s:%d", "web", 80)
+	format: "web:80"
+
+	format2: std.format("%05.1f%% of %v %q", 12.345, [1, 2], "x")
+	format2: "012.3% of [1,2] \"x\""
+
+	format3: std.format("no args")
+	format3: "no args"
+
+	format4: std.format("%s %v %v %x", 1, {a: "<b>"}, null, "hi")
+	format4: "1 {\"a\":\"<b>\"} null 6869"
+
+	template: std.template("""
+		{{range .servers}}server {{.host}}:{{.port}};
+		{{end}}
+		""", {servers: [{host: "a", port: 80}, {host: "b", port: 8080}]})
+	template: """
+		server a:80;
+		server b:8080;
+
+		"""
+
+	match: std.match("app.example.com", "^[a-z0-9.-]+$")
+	match: true
+
+	match2: std.match("App_1", "^[a-z0-9.-]+$")
+	match2: false
+
+	find: std.find("nginx:1.25-alpine", "[^:]+$")
+	find: "1.25-alpine"
+
+	find2: std.find("nginx", ":.*$")
+	find2: ""
+
+	findAll: std.findAll("a1b22c333", "[0-9]+")
+	findAll: ["1", "22", "333"]
+
+	findAll2: std.findAll("a1b22c333", "[0-9]+", 2)
+	findAll2: ["1", "22"]
+
+	findAll3: std.findAll("abc", "[0-9]+")
+	findAll3: []
+
+	regexReplace: std.regexReplace("registry.io/app:v1", ":(v[0-9]+)$", "@$1")
+	regexReplace: "registry.io/app@v1"
+
+	regexSplit: std.regexSplit("a, b;c", "[,;] *")
+	regexSplit: ["a", "b", "c"]
+
+	regexSplit2: std.regexSplit("abc", "")
+	regexSplit2: ["a", "b", "c"]
+
+	regexSplit3: std.regexSplit("a\u0000b,c", ",")
+	regexSplit3: ["a\u0000b", "c"]
+
+	map: std.map([1, 2, 3], {x: int, out: x * 2})
+	map: [2, 4, 6]
+
+	filter: std.filter([1, 2, 3, 4], {x: int, keep: x > 2})
+	filter: [3, 4]
+
+	reduce: std.reduce([1, 2, 3], 10, {acc: int, x: int, out: acc + x})
+	reduce: 16
+
+	reduce2: std.reduce([], "empty", {acc: _, x: _, out: x})
+	reduce2: "empty"
+
+	flatten: std.flatten([1, [2, [3, [4]]]])
+	flatten: [1, 2, 3, 4]
+
+	flatten2: std.flatten([1, [2, [3, [4]]]], 1)
+	flatten2: [1, 2, [3, [4]]]
+
+	unique: std.unique([1, 2, 1, "a", {a: 1}, "a", {a: 1}])
+	unique: [1, 2, "a", {a: 1}]
+
+	unique2: std.unique([1, 1.0, 2.5, 2.50, "1"])
+	unique2: [1, 2.5, "1"]
+
+	groupBy: std.groupBy([{name: "a", tier: "web"}, {name: "b", tier: "db"}, {name: "c", tier: "web"}], {x: _, key: x.tier})
+	groupBy: {
+		web: [{name: "a", tier: "web"}, {name: "c", tier: "web"}]
+		db: [{name: "b", tier: "db"}]
+	}
+
 	splitHostPort: std.splitHostPort("example.com:443")
 	splitHostPort: ["example.com", "443"]
 
@@ -158,6 +243,319 @@ localData: {
 	t:     merge.f.a == "b"
 	t:     merge.f.l[2] == 3
 
+	parseIP: std.parseIP("2001:0db8::0001")
+	parseIP: "2001:db8::1"
+
+	isIPv4: std.isIPv4("10.0.0.1")
+	isIPv4: true
+
+	isIPv4_2: std.isIPv4("::1")
+	isIPv4_2: false
+
+	isIPv6: std.isIPv6("::1")
+	isIPv6: true
+
+	isIPv6_2: std.isIPv6("example.com")
+	isIPv6_2: false
+
+	cidrContains: std.cidrContains("10.0.0.0/8", "10.1.2.3")
+	cidrContains: true
+
+	cidrContains2: std.cidrContains("10.0.0.0/8", "192.168.0.0/16")
+	cidrContains2: false
+
+	cidrHost: std.cidrHost("10.12.0.0/16", 5)
+	cidrHost: "10.12.0.5"
+
+	cidrHost2: std.cidrHost("10.12.0.0/16", -2)
+	cidrHost2: "10.12.255.254"
+
+	cidrHost3: std.cidrHost("fd00::/64", 16)
+	cidrHost3: "fd00::10"
+
+	cidrSubnet: std.cidrSubnet("10.0.0.0/16", 8, 3)
+	cidrSubnet: "10.0.3.0/24"
+
+	cidrSubnet2: std.cidrSubnet("fd00::/56", 8, 255)
+	cidrSubnet2: "fd00:0:0:ff::/64"
+
+	semverParse: std.semverParse("v1.24.3-rc.1+build.5")
+	semverParse: {major: 1, minor: 24, patch: 3, prerelease: "rc.1", build: "build.5"}
+
+	semverCompare: std.semverCompare("1.10.0", "1.9.9")
+	semverCompare: 1
+
+	semverCompare2: std.semverCompare("1.0.0-alpha", "1.0.0")
+	semverCompare2: -1
+
+	semverCompare3: std.semverCompare("1.0.0-alpha.2", "1.0.0-alpha.10")
+	semverCompare3: -1
+
+	semverCompare4: std.semverCompare("1.2.3+a", "1.2.3+b")
+	semverCompare4: 0
+
+	semverSatisfies: std.semverSatisfies("1.24.3", ">=1.20, <2")
+	semverSatisfies: true
+
+	semverSatisfies2: std.semverSatisfies("1.24.3", "~1.23.0 || ^2.0")
+	semverSatisfies2: false
+
+	semverSatisfies3: std.semverSatisfies("1.23.9", "~1.23.0 || ^2.0")
+	semverSatisfies3: true
+
+	semverSatisfies4: std.semverSatisfies("0.3.1", "^0.2")
+	semverSatisfies4: false
+
+	semverSatisfies5: std.semverSatisfies("1.24.3", "1.24.x")
+	semverSatisfies5: true
+
+	semverSatisfies6: std.semverSatisfies("1.24.3", "> 1.24")
+	semverSatisfies6: false
+
+	semverBump: std.semverBump("v1.24.3-rc.1", "minor")
+	semverBump: "v1.25.0"
+
+	semverBump2: std.semverBump("1.24.3", "patch")
+	semverBump2: "1.24.4"
+
+	urlParse: std.urlParse("https://user:pw@example.com:8443/a/b?x=1&y=2#top")
+	urlParse: {
+		scheme: "https"
+		userinfo: {username: "user", password: "pw"}
+		host:     "example.com"
+		port:     "8443"
+		path:     "/a/b"
+		query:    "x=1&y=2"
+		fragment: "top"
+	}
+
+	urlBuild: std.urlBuild(urlParse)
+	urlBuild: "https://user:pw@example.com:8443/a/b?x=1&y=2#top"
+
+	urlBuild2: std.urlBuild({scheme: "http", host: "::1", port: 8080, path: "/healthz", query: {verbose: true}})
+	urlBuild2: "http://[::1]:8080/healthz?verbose=true"
+
+	queryEncode: std.queryEncode({b: "x y", a: [1, 2]})
+	queryEncode: "a=1&a=2&b=x+y"
+
+	queryDecode: std.queryDecode("a=1&a=2&b=x+y")
+	queryDecode: {a: ["1", "2"], b: "x y"}
+
+	dnsName: std.dnsName("My_App.v2")
+	dnsName: "my-app-v2"
+
+	dnsName2: std.dnsName("123 Frontend--API ")
+	dnsName2: "frontend-api"
+
+	dnsName3: std.dnsName("feature/JIRA-1234_Add-a-really-long-branch-name-that-keeps-going-and-going", 30)
+	dnsName3: "feature-jira-1234-add-1f74f296"
+
+	shortHash: std.shortHash("hello", 12)
+	shortHash: "2cf24dba5fb0"
+
+	shortHash2: std.shortHash({a: 1})
+	shortHash2: "015abd7f"
+
+	uuidv5: std.uuidv5("dns", "example.com")
+	uuidv5: "cfbff0d1-9375-5685-968c-48ce8b15ae17"
+
+	uuidv52: std.uuidv5("6ba7b810-9dad-11d1-80b4-00c04fd430c8", "example.com")
+	uuidv52: "cfbff0d1-9375-5685-968c-48ce8b15ae17"
+
+	toTOML: std.toTOML({
+		title: "app"
+		port:  8080
+		tags: ["a", "b"]
+		server: http: {host: "0.0.0.0", "read timeout": 5}
+		backends: [{name: "a"}, {name: "b", tls: enabled: true}]
+	})
+	toTOML: """
+		port = 8080
+		tags = ['a', 'b']
+		title = 'app'
+
+		[[backends]]
+		name = 'a'
+
+		[[backends]]
+		name = 'b'
+
+		[backends.tls]
+		enabled = true
+
+		[server]
+		[server.http]
+		host = '0.0.0.0'
+		'read timeout' = 5
+
+		"""
+
+	fromTOML: std.fromTOML("""
+		# comment
+		title = "app"
+		port = 8_080
+		ratio = 0.5
+		hex = 0xff
+		released = 1979-05-27T07:32:00Z
+		tags = [
+		  "a",
+		  'b',
+		]
+		inline = { a = 1, b.c = "d" }
+
+		[server.http]
+		host = "0.0.0.0"
+
+		[[backends]]
+		name = "a"
+
+		[[backends]]
+		name = "b"
+		""")
+	fromTOML: {
+		title:    "app"
+		port:     8080
+		ratio:    0.5
+		hex:      255
+		released: "1979-05-27T07:32:00Z"
+		tags: ["a", "b"]
+		inline: {a: 1, b: c: "d"}
+		server: http: host: "0.0.0.0"
+		backends: [{name: "a"}, {name: "b"}]
+	}
+
+	toINI: std.toINI({name: "app", db: {host: "localhost", port: 5432, password: " secret"}})
+	toINI: """
+		name = app
+
+		[db]
+		host = localhost
+		password = " secret"
+		port = 5432
+
+		"""
+
+	fromINI: std.fromINI("""
+		; comment
+		name = app
+		[db]
+		host = localhost
+		password = " secret"
+		""")
+	fromINI: {name: "app", db: {host: "localhost", password: " secret"}}
+
+	toDotenv: std.toDotenv({PORT: 8080, URL: "http://example.com/", GREETING: "hello $USER\n"})
+	toDotenv: """
+		GREETING="hello \\$USER\\n"
+		PORT=8080
+		URL=http://example.com/
+
+		"""
+
+	fromDotenv: std.fromDotenv("""
+		# comment
+		export PORT=8080
+		NAME=app # trailing comment
+		GREETING="hello\\nworld"
+		RAW='$HOME'
+		""")
+	fromDotenv: {PORT: "8080", NAME: "app", GREETING: "hello\nworld", RAW: "$HOME"}
+
+	fromCSV: std.fromCSV("""
+		name,port
+		web,80
+		"api, internal",8080
+		""")
+	fromCSV: [{name: "web", port: "80"}, {name: "api, internal", port: "8080"}]
+
+	fromCSV2: std.fromCSV("name;port\nweb;80", ";")
+	fromCSV2: [{name: "web", port: "80"}]
+
+	min: std.min(3, 1, 2)
+	min: 1
+
+	min2: std.min([3, 1.5, 2])
+	min2: 1.5
+
+	max: std.max(3, 1, 2)
+	max: 3
+
+	max2: std.max([3, 1, 4])
+	max2: 4
+
+	sum: std.sum(1, 2, 3.5)
+	sum: 6.5
+
+	sum2: std.sum([1, 2, 3])
+	sum2: 6
+
+	abs: std.abs(-2)
+	abs: 2
+
+	abs2: std.abs([-1, 2, -3.5])
+	abs2: [1, 2, 3.5]
+
+	ceil: std.ceil(1.2)
+	ceil: 2
+
+	floor: std.floor(1.8)
+	floor: 1
+
+	floor2: std.floor([1.8, -1.2])
+	floor2: [1, -2]
+
+	round: std.round(2.5)
+	round: 3
+
+	round2: std.round(2.4)
+	round2: 2
+
+	clamp: std.clamp(12, 1, 10)
+	clamp: 10
+
+	clamp2: std.clamp(-3, 1, 10)
+	clamp2: 1
+
+	clamp3: std.clamp(5, 1, 10)
+	clamp3: 5
+
+	keys: std.keys({b: 1, a: 2, c: 3})
+	keys: ["a", "b", "c"]
+
+	values: std.values({b: 1, a: 2, c: 3})
+	values: [2, 1, 3]
+
+	items: std.items({b: 1, a: 2})
+	items: [{key: "a", value: 2}, {key: "b", value: 1}]
+
+	pick: std.pick({a: 1, b: 2, c: 3}, ["a", "c", "d"])
+	pick: {a: 1, c: 3}
+
+	omit: std.omit({a: 1, b: 2, c: 3}, ["a", "c", "d"])
+	omit: {b: 2}
+
+	hasKey: std.hasKey({a: 1}, "a")
+	hasKey: true
+
+	hasKey2: std.hasKey({a: 1}, "b")
+	hasKey2: false
+
+	deepMerge: std.deepMerge({a: 1, m: {x: 1, l: [1]}, l: [1, 2]}, {b: 2, m: {y: 2, l: [2]}, l: [3]})
+	deepMerge: {a: 1, b: 2, m: {x: 1, y: 2, l: [2]}, l: [3]}
+
+	deepMerge2: std.deepMerge({m: {l: [1]}, l: [1, 2]}, {m: {l: [2]}, l: [3]}, {lists: "append"})
+	deepMerge2: {m: {l: [1, 2]}, l: [1, 2, 3]}
+
+	deepMerge3: std.deepMerge({
+		env: [{name: "A", value: "1"}, {name: "B", value: "2"}, "raw"]
+	}, {
+		env: [{name: "B", value: "3", secret: true}, {name: "C", value: "4"}, "raw2"]
+	}, {lists: "merge"})
+	deepMerge3: env: [{name: "A", value: "1"}, {name: "B", value: "3", secret: true}, "raw", {name: "C", value: "4"}, "raw2"]
+
+	deepMerge4: std.deepMerge({l: [{id: 1, a: 1}]}, {l: [{id: 1, b: 2}]}, {lists: "merge", key: "id"})
+	deepMerge4: l: [{id: 1, a: 1, b: 2}]
+
 	mod1: mod(3, 2)
 	mod1: 1
 	mod1: mod(3, 2)
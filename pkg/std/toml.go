@@ -0,0 +1,99 @@
+package std
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// toTOML encodes a struct as a TOML document. Structs become tables and lists
+// of structs arrays of tables, all other lists are written inline.
+func toTOML(args []any) (any, error) {
+	table, err := tomlValue(args[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := toml.NewEncoder(buf).Encode(table); err != nil {
+		return nil, err
+	}
+	return buf.String(), nil
+}
+
+// tomlValue converts v, as decoded from JSON, to the value to encode. Whole
+// numbers are encoded as integers.
+func tomlValue(v any, path []string) (any, error) {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v), nil
+		}
+		return v, nil
+	case []any:
+		result := make([]any, 0, len(v))
+		for i, item := range v {
+			item, err := tomlValue(item, append(path, fmt.Sprint(i)))
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, item)
+		}
+		return result, nil
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, value := range v {
+			value, err := tomlValue(value, append(path, key))
+			if err != nil {
+				return nil, err
+			}
+			result[key] = value
+		}
+		return result, nil
+	case nil:
+		return nil, fmt.Errorf("%s: TOML has no null value", strings.Join(path, "."))
+	}
+	return v, nil
+}
+
+// fromTOML decodes a TOML document. Dates and times are returned as strings.
+func fromTOML(args []any) (any, error) {
+	s, _ := args[0].(string)
+	result := map[string]any{}
+	if err := toml.Unmarshal([]byte(s), &result); err != nil {
+		var derr *toml.DecodeError
+		if errors.As(err, &derr) {
+			line, column := derr.Position()
+			return nil, fmt.Errorf("line %d, column %d: %s", line, column, strings.TrimPrefix(derr.Error(), "toml: "))
+		}
+		return nil, errors.New(strings.TrimPrefix(err.Error(), "toml: "))
+	}
+	return result, checkTOMLNumbers(result, nil)
+}
+
+// checkTOMLNumbers returns an error if v holds an infinite or NaN float, which
+// AML can not represent. Dates and times are encoded as strings as is.
+func checkTOMLNumbers(v any, path []string) error {
+	switch v := v.(type) {
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return fmt.Errorf("%s: %v can not be represented in AML", strings.Join(path, "."), v)
+		}
+	case []any:
+		for i, item := range v {
+			if err := checkTOMLNumbers(item, append(path, fmt.Sprint(i))); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		for key, value := range v {
+			if err := checkTOMLNumbers(value, append(path, key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
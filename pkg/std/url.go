@@ -0,0 +1,156 @@
+package std
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// urlParse splits a URL into the fields scheme, userinfo (username and
+// password), host, port, path, query and fragment. The query is returned
+// encoded, it can be decoded with queryDecode.
+func urlParse(args []any) (any, error) {
+	s, _ := args[0].(string)
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	password, _ := u.User.Password()
+	return map[string]any{
+		"scheme": u.Scheme,
+		"userinfo": map[string]any{
+			"username": u.User.Username(),
+			"password": password,
+		},
+		"host":     u.Hostname(),
+		"port":     u.Port(),
+		"path":     u.Path,
+		"query":    u.RawQuery,
+		"fragment": u.Fragment,
+	}, nil
+}
+
+// urlBuild is the inverse of urlParse. All fields are optional and the query
+// may also be given as a struct, which is encoded with queryEncode.
+func urlBuild(args []any) (any, error) {
+	fields, _ := args[0].(map[string]any)
+	for key := range fields {
+		switch key {
+		case "scheme", "userinfo", "host", "port", "path", "query", "fragment":
+		default:
+			return nil, fmt.Errorf("unknown URL field %q", key)
+		}
+	}
+
+	u := &url.URL{}
+	if err := stringField(fields, "scheme", &u.Scheme); err != nil {
+		return nil, err
+	}
+	if err := stringField(fields, "path", &u.Path); err != nil {
+		return nil, err
+	}
+	if err := stringField(fields, "fragment", &u.Fragment); err != nil {
+		return nil, err
+	}
+
+	var host, port string
+	if err := stringField(fields, "host", &host); err != nil {
+		return nil, err
+	}
+	if p, ok := fields["port"].(float64); ok {
+		port = strconv.FormatFloat(p, 'f', -1, 64)
+	} else if err := stringField(fields, "port", &port); err != nil {
+		return nil, err
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+
+	if userinfo, ok := fields["userinfo"].(map[string]any); ok {
+		var username, password string
+		if err := stringField(userinfo, "username", &username); err != nil {
+			return nil, err
+		}
+		if err := stringField(userinfo, "password", &password); err != nil {
+			return nil, err
+		}
+		if password != "" {
+			u.User = url.UserPassword(username, password)
+		} else if username != "" {
+			u.User = url.User(username)
+		}
+	}
+
+	switch query := fields["query"].(type) {
+	case nil:
+	case string:
+		u.RawQuery = query
+	case map[string]any:
+		encoded, err := queryEncode([]any{query})
+		if err != nil {
+			return nil, err
+		}
+		u.RawQuery = encoded.(string)
+	default:
+		return nil, fmt.Errorf("query must be a string or struct")
+	}
+
+	return u.String(), nil
+}
+
+// queryEncode encodes a struct as a URL query string. Values may be strings,
+// numbers, bools or lists of them, which repeat the key.
+func queryEncode(args []any) (any, error) {
+	fields, _ := args[0].(map[string]any)
+	values := url.Values{}
+	for key, value := range fields {
+		list, ok := value.([]any)
+		if !ok {
+			list = []any{value}
+		}
+		for _, v := range list {
+			switch v.(type) {
+			case string, float64, bool:
+				values.Add(key, fmt.Sprint(v))
+			default:
+				return nil, fmt.Errorf("query value of %q must be a string, number or bool", key)
+			}
+		}
+	}
+	return values.Encode(), nil
+}
+
+// queryDecode decodes a URL query string into a struct. Keys given once have
+// a string value, repeated keys a list of strings.
+func queryDecode(args []any) (any, error) {
+	s, _ := args[0].(string)
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]any{}
+	for key, list := range values {
+		if len(list) == 1 {
+			result[key] = list[0]
+		} else {
+			result[key] = list
+		}
+	}
+	return result, nil
+}
+
+func stringField(fields map[string]any, key string, target *string) error {
+	v, ok := fields[key]
+	if !ok {
+		return nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("URL field %q must be a string", key)
+	}
+	*target = s
+	return nil
+}